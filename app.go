@@ -364,6 +364,8 @@ type DownloadRequest struct {
 	UseSingleGenre       bool   `json:"use_single_genre,omitempty"`
 	EmbedGenre           bool   `json:"embed_genre,omitempty"`
 	Separator            string `json:"separator,omitempty"`
+	Profile              string `json:"profile,omitempty"`
+	Explicit             *bool  `json:"explicit,omitempty"`
 }
 
 type DownloadResponse struct {
@@ -561,6 +563,9 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 	spotifyURL := ""
 	if req.SpotifyID != "" {
 		spotifyURL = fmt.Sprintf("https://open.spotify.com/track/%s", req.SpotifyID)
+		if req.Explicit != nil {
+			backend.SetSpotifyTrackExplicit(req.SpotifyID, *req.Explicit)
+		}
 	}
 
 	metadataSeparator := req.Separator
@@ -626,18 +631,28 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 		}
 	}
 
+	pipelineProfile := backend.ResolvePipelineProfile(req.Profile)
+
+	req.TrackName, req.ArtistName = backend.ApplyFeaturedArtistPolicy(req.TrackName, req.ArtistName, metadataSeparator, backend.GetFeaturedArtistPolicySetting())
+
 	if req.TrackName != "" && req.ArtistName != "" {
 		expectedFilename := backend.BuildExpectedFilename(req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.SpotifyDiscNumber, req.UseAlbumTrackNumber, req.ISRC)
 		expectedPath := filepath.Join(req.OutputDir, expectedFilename)
 
 		if !backend.GetRedownloadWithSuffixSetting() {
+			existingPath := ""
 			if fileInfo, err := os.Stat(expectedPath); err == nil && fileInfo.Size() > 100*1024 {
+				existingPath = expectedPath
+			} else if pipelineProfile.RelocatesFile() {
+				existingPath = backend.FindHistoryPathBySpotifyID(req.SpotifyID, "SpotiFLAC")
+			}
 
-				backend.SkipDownloadItem(itemID, expectedPath)
+			if existingPath != "" {
+				backend.SkipDownloadItem(itemID, existingPath)
 				return DownloadResponse{
 					Success:       true,
 					Message:       "File already exists",
-					File:          expectedPath,
+					File:          existingPath,
 					AlreadyExists: true,
 					ItemID:        itemID,
 				}, nil
@@ -645,7 +660,7 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 		}
 	}
 
-	lyricsChan := make(chan string, 1)
+	lyricsChan := make(chan *backend.LyricsResponse, 1)
 	isrcChan := make(chan string, 1)
 
 	if req.SpotifyID != "" {
@@ -653,11 +668,13 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 			go func() {
 				client := backend.NewLyricsClient()
 				resp, _, err := client.FetchLyricsAllSources(req.SpotifyID, req.TrackName, req.ArtistName, req.AlbumName, req.Duration)
-				if err == nil && resp != nil && len(resp.Lines) > 0 {
-					lrc := client.ConvertToLRC(resp, req.TrackName, req.ArtistName)
-					lyricsChan <- lrc
+				if errors.Is(err, backend.ErrLyricsInstrumental) {
+					fmt.Printf("Track is instrumental: %s\n", req.TrackName)
+					lyricsChan <- backend.InstrumentalLyrics(backend.GetLyricsInstrumentalMarkerSetting())
+				} else if err == nil && resp != nil && len(resp.Lines) > 0 {
+					lyricsChan <- resp
 				} else {
-					lyricsChan <- ""
+					lyricsChan <- nil
 				}
 			}()
 		} else {
@@ -751,10 +768,46 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 	}
 
 	if !alreadyExists {
-		validated, validationErr := backend.ValidateDownloadedTrackDuration(filename, req.Duration)
-		if validationErr != nil {
-			cleanupInvalidDownloadArtifacts(filename)
-			errorMessage := validationErr.Error()
+		pipeline := &backend.PipelineContext{
+			ItemID:   itemID,
+			FilePath: filename,
+			Track: backend.PipelineTrack{
+				SpotifyID:            req.SpotifyID,
+				TrackName:            req.TrackName,
+				ArtistName:           req.ArtistName,
+				AlbumName:            req.AlbumName,
+				AlbumArtist:          req.AlbumArtist,
+				ReleaseDate:          req.ReleaseDate,
+				ISRC:                 req.ISRC,
+				CoverURL:             req.CoverURL,
+				Copyright:            req.Copyright,
+				Publisher:            req.Publisher,
+				Composer:             req.Composer,
+				Separator:            metadataSeparator,
+				PlaylistName:         req.PlaylistName,
+				OutputDir:            req.OutputDir,
+				TrackNumber:          req.SpotifyTrackNumber,
+				DiscNumber:           req.SpotifyDiscNumber,
+				TotalTracks:          req.SpotifyTotalTracks,
+				TotalDiscs:           req.SpotifyTotalDiscs,
+				Duration:             req.Duration,
+				EmbedLyrics:          req.EmbedLyrics,
+				EmbedMaxQualityCover: req.EmbedMaxQualityCover,
+			},
+			FetchLyrics: func() *backend.LyricsResponse {
+				return <-lyricsChan
+			},
+		}
+
+		if pipelineErr := backend.RunDownloadPipeline(pipelineProfile, pipeline); pipelineErr != nil {
+			errorMessage := pipelineErr.Error()
+			var stageErr *backend.PipelineStageError
+			if errors.As(pipelineErr, &stageErr) {
+				errorMessage = stageErr.Message
+				if stageErr.RemoveFile {
+					cleanupInvalidDownloadArtifacts(pipeline.FilePath)
+				}
+			}
 			backend.FailDownloadItem(itemID, errorMessage)
 			return DownloadResponse{
 				Success: false,
@@ -762,35 +815,12 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 				ItemID:  itemID,
 			}, errors.New(errorMessage)
 		}
-		if !validated {
-			fmt.Printf("[DownloadValidation] Skipped duration validation for %s (expected=%ds)\n", filename, req.Duration)
-		}
+		filename = pipeline.FilePath
 	}
 
-	if !alreadyExists && req.SpotifyID != "" && req.EmbedLyrics && (strings.HasSuffix(filename, ".flac") || strings.HasSuffix(filename, ".mp3") || strings.HasSuffix(filename, ".m4a")) {
-		fmt.Printf("\nWaiting for lyrics fetch to complete...\n")
-		lyrics := <-lyricsChan
-		if lyrics != "" {
-			fmt.Printf("\n--- Full LRC Content ---\n")
-			fmt.Println(lyrics)
-			fmt.Printf("--- End LRC Content ---\n\n")
-
-			fmt.Printf("Embedding into: %s\n", filename)
-
-			if err := backend.EmbedLyricsOnlyUniversal(filename, lyrics); err != nil {
-				fmt.Printf("Failed to embed lyrics: %v\n", err)
-			} else {
-				fmt.Printf("Lyrics embedded successfully!\n")
-			}
-		} else {
-			fmt.Println("No lyrics found to embed.")
-		}
-	} else {
-
-		select {
-		case <-lyricsChan:
-		default:
-		}
+	select {
+	case <-lyricsChan:
+	default:
 	}
 
 	message := "Download completed successfully"
@@ -798,15 +828,6 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 		message = "File already exists"
 		backend.SkipDownloadItem(itemID, filename)
 	} else {
-		if strings.EqualFold(filepath.Ext(filename), ".flac") && req.CoverURL != "" {
-			coverClient := backend.NewCoverClient()
-			if iconErr := coverClient.ApplyMacOSFLACFileIcon(filename, req.CoverURL, 256, req.EmbedMaxQualityCover); iconErr != nil {
-				fmt.Printf("Warning: failed to set macOS FLAC file icon: %v\n", iconErr)
-			} else {
-				fmt.Printf("macOS FLAC file icon set: %s\n", filename)
-			}
-		}
-
 		if fileInfo, statErr := os.Stat(filename); statErr == nil {
 			finalSize := float64(fileInfo.Size()) / (1024 * 1024)
 			backend.CompleteDownloadItem(itemID, filename, finalSize)
@@ -1625,6 +1646,27 @@ func (a *App) DownloadLyrics(req LyricsDownloadRequest) (backend.LyricsDownloadR
 	return *resp, nil
 }
 
+func (a *App) ConvertLyricsFile(inputPath string, format string) (string, error) {
+	if strings.TrimSpace(inputPath) == "" {
+		return "", fmt.Errorf("lyrics file path is required")
+	}
+
+	client := backend.NewLyricsClient()
+	return client.ConvertLyricsFile(inputPath, format)
+}
+
+func (a *App) RunLyricsBatch(req backend.LyricsBatchRequest) (*backend.LyricsBatchSummary, error) {
+	client := backend.NewLyricsClient()
+	return client.RunLyricsBatch(req, func(progress backend.LyricsBatchProgress) {
+		runtime.EventsEmit(a.ctx, "lyrics-batch:progress", progress)
+	})
+}
+
+func (a *App) EditLyrics(req backend.LyricsEditRequest) (*backend.LyricsEditResponse, error) {
+	client := backend.NewLyricsClient()
+	return client.EditLyrics(req)
+}
+
 type CoverDownloadRequest struct {
 	CoverURL       string `json:"cover_url"`
 	TrackName      string `json:"track_name"`
@@ -1673,6 +1715,13 @@ func (a *App) DownloadCover(req CoverDownloadRequest) (backend.CoverDownloadResp
 	return *resp, nil
 }
 
+func (a *App) RunCoverAudit(req backend.CoverAuditRequest) (*backend.CoverAuditSummary, error) {
+	client := backend.NewCoverClient()
+	return client.RunCoverAudit(req, func(progress backend.CoverAuditProgress) {
+		runtime.EventsEmit(a.ctx, "cover-audit:progress", progress)
+	})
+}
+
 type HeaderDownloadRequest struct {
 	HeaderURL  string `json:"header_url"`
 	ArtistName string `json:"artist_name"`
@@ -1849,6 +1898,30 @@ func (a *App) DownloadFFmpeg() DownloadFFmpegResponse {
 	}
 }
 
+func (a *App) UpgradeFFmpeg(version string) DownloadFFmpegResponse {
+	runtime.EventsEmit(a.ctx, "ffmpeg:status", "starting")
+	state, err := backend.UpgradeFFmpeg(version, func(progress int) {
+		runtime.EventsEmit(a.ctx, "ffmpeg:progress", progress)
+	})
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "ffmpeg:status", "failed")
+		return DownloadFFmpegResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	runtime.EventsEmit(a.ctx, "ffmpeg:status", "completed")
+	return DownloadFFmpegResponse{
+		Success: true,
+		Message: fmt.Sprintf("FFmpeg %s installed successfully", state.Version),
+	}
+}
+
+func (a *App) GetFFmpegInstallState() (*backend.FFmpegInstallState, error) {
+	return backend.LoadFFmpegInstallState()
+}
+
 func (a *App) GetBrewPath() string {
 	return backend.GetBrewPath()
 }
@@ -1889,6 +1962,8 @@ type ConvertAudioRequest struct {
 	OutputFormat string   `json:"output_format"`
 	Bitrate      string   `json:"bitrate"`
 	Codec        string   `json:"codec"`
+	Preset       string   `json:"preset"`
+	JobID        string   `json:"job_id"`
 }
 
 func (a *App) ConvertAudio(req ConvertAudioRequest) ([]backend.ConvertAudioResult, error) {
@@ -1897,23 +1972,61 @@ func (a *App) ConvertAudio(req ConvertAudioRequest) ([]backend.ConvertAudioResul
 		OutputFormat: req.OutputFormat,
 		Bitrate:      req.Bitrate,
 		Codec:        req.Codec,
+		Preset:       req.Preset,
+		JobID:        req.JobID,
 	}
-	return backend.ConvertAudio(backendReq)
+	return backend.ConvertAudio(backendReq, a.emitAudioJobProgress)
 }
 
 type ResampleAudioRequest struct {
-	InputFiles []string `json:"input_files"`
-	SampleRate string   `json:"sample_rate"`
-	BitDepth   string   `json:"bit_depth"`
+	InputFiles         []string `json:"input_files"`
+	SampleRate         string   `json:"sample_rate"`
+	BitDepth           string   `json:"bit_depth"`
+	Resampler          string   `json:"resampler"`
+	Precision          int      `json:"precision"`
+	Dither             string   `json:"dither"`
+	NoiseShaping       string   `json:"noise_shaping"`
+	OutputMode         string   `json:"output_mode"`
+	OutputRoot         string   `json:"output_root"`
+	Backup             bool     `json:"backup"`
+	OnlyDownsample     bool     `json:"only_downsample"`
+	OnlyReduceBitDepth bool     `json:"only_reduce_bit_depth"`
+	JobID              string   `json:"job_id"`
 }
 
 func (a *App) ResampleAudio(req ResampleAudioRequest) ([]backend.ResampleResult, error) {
 	backendReq := backend.ResampleRequest{
-		InputFiles: req.InputFiles,
-		SampleRate: req.SampleRate,
-		BitDepth:   req.BitDepth,
+		InputFiles:         req.InputFiles,
+		SampleRate:         req.SampleRate,
+		BitDepth:           req.BitDepth,
+		Resampler:          req.Resampler,
+		Precision:          req.Precision,
+		Dither:             req.Dither,
+		NoiseShaping:       req.NoiseShaping,
+		OutputMode:         req.OutputMode,
+		OutputRoot:         req.OutputRoot,
+		Backup:             req.Backup,
+		OnlyDownsample:     req.OnlyDownsample,
+		OnlyReduceBitDepth: req.OnlyReduceBitDepth,
+		JobID:              req.JobID,
 	}
-	return backend.ResampleAudio(backendReq)
+	return backend.ResampleAudio(backendReq, a.emitAudioJobProgress)
+}
+
+func (a *App) MirrorLibrary(req backend.MirrorLibraryRequest) (*backend.MirrorLibrarySummary, error) {
+	return backend.MirrorLibrary(req, a.emitAudioJobProgress)
+}
+
+func (a *App) RecompressFlac(req backend.FlacRecompressRequest) (*backend.FlacRecompressSummary, error) {
+	return backend.RecompressFlac(req, a.emitAudioJobProgress)
+}
+
+func (a *App) emitAudioJobProgress(progress backend.AudioJobProgress) {
+	runtime.EventsEmit(a.ctx, "audio-job:progress", progress)
+}
+
+func (a *App) CancelAudioJob(jobID string) bool {
+	return backend.CancelAudioJob(jobID)
 }
 
 func (a *App) SelectAudioFiles() ([]string, error) {
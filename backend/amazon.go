@@ -390,8 +390,8 @@ func (a *AmazonDownloader) DownloadByURL(amazonURL, outputDir, quality, filename
 			safeAlbumArtist = sanitizeFilename(GetFirstArtist(spotifyAlbumArtist))
 		}
 
-		safeTitle := sanitizeFilename(spotifyTrackName)
-		safeAlbum := sanitizeFilename(spotifyAlbumName)
+		safeTitle := sanitizeFilename(ResolveFilenameTitle(spotifyTrackName))
+		safeAlbum := sanitizeFilename(ResolveFilenameTitle(spotifyAlbumName))
 
 		year := ""
 		if len(spotifyReleaseDate) >= 4 {
@@ -460,19 +460,13 @@ func (a *AmazonDownloader) DownloadByURL(amazonURL, outputDir, quality, filename
 
 	fmt.Println("Embedding Spotify metadata...")
 
-	coverPath := ""
-
-	if spotifyCoverURL != "" {
-		coverPath = filePath + ".cover.jpg"
-		coverClient := NewCoverClient()
-		if err := coverClient.DownloadCoverToPath(spotifyCoverURL, coverPath, embedMaxQualityCover); err != nil {
-			fmt.Printf("Warning: Failed to download Spotify cover: %v\n", err)
-			coverPath = ""
-		} else {
-			defer os.Remove(coverPath)
-			fmt.Println("Spotify cover downloaded")
-		}
-	}
+	coverPath, cleanupCover := prepareTrackArtwork(filePath, spotifyCoverURL, embedMaxQualityCover, CoverLookup{
+		SpotifyURL: spotifyURL,
+		TrackName:  spotifyTrackName,
+		ArtistName: spotifyArtistName,
+		AlbumName:  spotifyAlbumName,
+	})
+	defer cleanupCover()
 
 	trackNumberToEmbed := spotifyTrackNumber
 	if trackNumberToEmbed == 0 {
@@ -498,7 +492,8 @@ func (a *AmazonDownloader) DownloadByURL(amazonURL, outputDir, quality, filename
 		Description: "https://github.com/spotbye/SpotiFLAC",
 		ISRC:        isrc,
 		UPC:         upc,
-		Genre:       mbMeta.Genre,
+		Genre:       ResolveGenreFromSources(GenreSourceInput{MusicBrainz: mbMeta.Genre, SpotifyTrackID: spotifyURL, ISRC: isrc, TrackName: spotifyTrackName, ArtistName: spotifyArtistName, AlbumName: spotifyAlbumName}, useSingleGenre, embedGenre),
+		Advisory:    ResolveContentAdvisory(spotifyURL, spotifyTrackName, false),
 	}
 
 	if err := EmbedMetadataToConvertedFile(filePath, metadata, coverPath); err != nil {
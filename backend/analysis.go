@@ -41,9 +41,42 @@ func GetTrackMetadata(filepath string) (*AnalysisResult, error) {
 		return nil, fmt.Errorf("file does not exist: %s", filepath)
 	}
 
+	if res, err := getMetadataNative(filepath); err == nil {
+		return res, nil
+	}
+
 	return GetMetadataWithFFprobe(filepath)
 }
 
+func getMetadataNative(filePath string) (*AnalysisResult, error) {
+	info, err := readNativeAudioInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.SampleRate == 0 || info.Duration <= 0 {
+		return nil, fmt.Errorf("incomplete audio info for %s", filePath)
+	}
+
+	res := &AnalysisResult{
+		FilePath:      filePath,
+		SampleRate:    info.SampleRate,
+		Channels:      info.Channels,
+		BitsPerSample: info.BitsPerSample,
+		TotalSamples:  info.TotalSamples,
+		Duration:      info.Duration,
+		Bitrate:       info.Bitrate,
+		BitDepth:      "Unknown",
+	}
+	if stat, err := os.Stat(filePath); err == nil {
+		res.FileSize = stat.Size()
+	}
+	if info.BitsPerSample > 0 {
+		res.BitDepth = fmt.Sprintf("%d-bit", info.BitsPerSample)
+	}
+
+	return res, nil
+}
+
 func GetMetadataWithFFprobe(filePath string) (*AnalysisResult, error) {
 	ffprobePath, err := GetFFprobePath()
 	if err != nil {
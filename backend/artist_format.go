@@ -1,6 +1,11 @@
 package backend
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 func normalizeArtistSeparator(separator string) string {
 	separator = strings.TrimSpace(separator)
@@ -88,3 +93,210 @@ func SplitMetadataValues(value, separator string) []string {
 
 	return result
 }
+
+const (
+	featuredArtistPolicyKeep   = "keep"
+	featuredArtistPolicyArtist = "artist"
+	featuredArtistPolicyTitle  = "title"
+)
+
+var (
+	featuredTitleBracketPattern  = regexp.MustCompile(`(?i)\s*[\(\[]\s*(?:feat\.?|ft\.?|featuring)\s+([^\)\]]+)[\)\]]`)
+	featuredTitleTrailingPattern = regexp.MustCompile(`(?i)\s+(?:feat\.|ft\.|featuring)\s+(.+)$`)
+	featuredNameSplitPattern     = regexp.MustCompile(`\s*(?:,|&|\s+and\s+)\s*`)
+	artistSortArticles           = []string{"The", "A", "An"}
+)
+
+func normalizeFeaturedArtistPolicy(value string) string {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case featuredArtistPolicyArtist:
+		return featuredArtistPolicyArtist
+	case featuredArtistPolicyTitle:
+		return featuredArtistPolicyTitle
+	default:
+		return featuredArtistPolicyKeep
+	}
+}
+
+func extractFeaturedFromTitle(title string) (string, []string) {
+	var chunks []string
+	for _, match := range featuredTitleBracketPattern.FindAllStringSubmatch(title, -1) {
+		chunks = append(chunks, match[1])
+	}
+	cleaned := featuredTitleBracketPattern.ReplaceAllString(title, "")
+
+	if match := featuredTitleTrailingPattern.FindStringSubmatch(cleaned); match != nil {
+		chunks = append(chunks, match[1])
+		cleaned = featuredTitleTrailingPattern.ReplaceAllString(cleaned, "")
+	}
+
+	return strings.TrimSpace(cleaned), chunks
+}
+
+func indexArtistName(s, name string) (int, int) {
+	for i := 0; i < len(s); {
+		if n, ok := hasPrefixFold(s[i:], name); ok && isArtistNameBoundary(s, i, i+n) {
+			return i, i + n
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return -1, -1
+}
+
+func isArtistNameBoundary(s string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && (unicode.IsLetter(before) || unicode.IsDigit(before)) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && (unicode.IsLetter(after) || unicode.IsDigit(after)) {
+		return false
+	}
+	return true
+}
+
+func hasPrefixFold(s, prefix string) (int, bool) {
+	n := 0
+	for _, want := range prefix {
+		if n >= len(s) {
+			return 0, false
+		}
+		got, size := utf8.DecodeRuneInString(s[n:])
+		if got != want && !strings.EqualFold(string(got), string(want)) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+func mentionedFeaturedArtists(chunks []string, known []string) []string {
+	var result []string
+	for _, name := range known {
+		if name == "" {
+			continue
+		}
+		for _, chunk := range chunks {
+			if start, _ := indexArtistName(chunk, name); start >= 0 {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func splitFeaturedArtists(chunks []string, known []string) []string {
+	seen := make(map[string]struct{}, len(known))
+	for _, name := range known {
+		seen[strings.ToLower(name)] = struct{}{}
+	}
+
+	var result []string
+	for _, chunk := range chunks {
+		remaining := chunk
+		for _, name := range known {
+			if name == "" {
+				continue
+			}
+			if start, end := indexArtistName(remaining, name); start >= 0 {
+				remaining = remaining[:start] + "," + remaining[end:]
+			}
+		}
+
+		for _, part := range featuredNameSplitPattern.Split(remaining, -1) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			key := strings.ToLower(part)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, part)
+		}
+	}
+
+	return result
+}
+
+func formatFeaturedCredit(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "feat. " + names[0]
+	default:
+		return "feat. " + strings.Join(names[:len(names)-1], ", ") + " & " + names[len(names)-1]
+	}
+}
+
+func ApplyFeaturedArtistPolicy(title, artist, separator, policy string) (string, string) {
+	policy = normalizeFeaturedArtistPolicy(policy)
+	if policy == featuredArtistPolicyKeep || strings.TrimSpace(title) == "" {
+		return title, artist
+	}
+
+	artists := SplitArtistCredits(artist, separator)
+	if len(artists) == 0 {
+		return title, artist
+	}
+	joiner := displayMetadataSeparator(separator)
+
+	cleanTitle, chunks := extractFeaturedFromTitle(title)
+
+	switch policy {
+	case featuredArtistPolicyArtist:
+		if len(chunks) == 0 {
+			return title, artist
+		}
+		featured := splitFeaturedArtists(chunks, artists)
+		return cleanTitle, strings.Join(append(artists, featured...), joiner)
+	case featuredArtistPolicyTitle:
+		if len(chunks) == 0 {
+			return title, artist
+		}
+		guests := mentionedFeaturedArtists(chunks, artists[1:])
+		guests = append(guests, splitFeaturedArtists(chunks, artists)...)
+		main := []string{artists[0]}
+		for _, name := range artists[1:] {
+			featured := false
+			for _, guest := range guests {
+				if strings.EqualFold(guest, name) {
+					featured = true
+					break
+				}
+			}
+			if !featured {
+				main = append(main, name)
+			}
+		}
+		if len(guests) == 0 {
+			return cleanTitle, strings.Join(main, joiner)
+		}
+		return cleanTitle + " (" + formatFeaturedCredit(guests) + ")", strings.Join(main, joiner)
+	}
+
+	return title, artist
+}
+
+func ArtistSortName(name string) string {
+	name = strings.TrimSpace(name)
+	for _, article := range artistSortArticles {
+		prefix := article + " "
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return strings.TrimSpace(name[len(prefix):]) + ", " + name[:len(article)]
+		}
+	}
+	return name
+}
+
+func ArtistSortNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if sortName := ArtistSortName(name); sortName != "" {
+			result = append(result, sortName)
+		}
+	}
+	return result
+}
@@ -0,0 +1,282 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+	"github.com/go-flac/flacpicture"
+	"github.com/go-flac/go-flac"
+)
+
+const (
+	albumArtworkSidecarNone   = "none"
+	albumArtworkSidecarCover  = "cover"
+	albumArtworkSidecarFolder = "folder"
+	albumArtworkSidecarBoth   = "both"
+
+	artworkKindFront  = "front"
+	artworkKindBack   = "back"
+	artworkKindArtist = "artist"
+
+	defaultEmbeddedCoverSize = 1000
+)
+
+var (
+	albumArtworkSidecarMu     sync.Mutex
+	albumArtworkSidecarOwners = map[string]*albumArtworkSidecarOwner{}
+)
+
+type albumArtworkSidecarOwner struct {
+	albumKey string
+	written  []string
+	mixed    bool
+}
+
+type artworkPicture struct {
+	Kind string
+	Path string
+}
+
+func normalizeAlbumArtworkSidecar(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case albumArtworkSidecarCover:
+		return albumArtworkSidecarCover
+	case albumArtworkSidecarFolder:
+		return albumArtworkSidecarFolder
+	case albumArtworkSidecarBoth:
+		return albumArtworkSidecarBoth
+	default:
+		return albumArtworkSidecarNone
+	}
+}
+
+func albumArtworkSidecarNames(policy string) []string {
+	switch normalizeAlbumArtworkSidecar(policy) {
+	case albumArtworkSidecarCover:
+		return []string{"cover.jpg"}
+	case albumArtworkSidecarFolder:
+		return []string{"folder.jpg"}
+	case albumArtworkSidecarBoth:
+		return []string{"cover.jpg", "folder.jpg"}
+	default:
+		return nil
+	}
+}
+
+func (p artworkPicture) description() string {
+	switch p.Kind {
+	case artworkKindBack:
+		return "Back cover"
+	case artworkKindArtist:
+		return "Artist"
+	default:
+		return "Cover"
+	}
+}
+
+func (p artworkPicture) flacType() flacpicture.PictureType {
+	switch p.Kind {
+	case artworkKindBack:
+		return flacpicture.PictureTypeBackCover
+	case artworkKindArtist:
+		return flacpicture.PictureTypeArtist
+	default:
+		return flacpicture.PictureTypeFrontCover
+	}
+}
+
+func (p artworkPicture) id3Type() byte {
+	switch p.Kind {
+	case artworkKindBack:
+		return id3v2.PTBackCover
+	case artworkKindArtist:
+		return id3v2.PTArtistPerformer
+	default:
+		return id3v2.PTFrontCover
+	}
+}
+
+func artworkMimeType(data []byte) string {
+	if bytes.HasPrefix(data, []byte{0x89, 'P', 'N', 'G'}) {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+func WriteAlbumArtworkSidecars(albumDir, coverPath, policy, albumKey string) []string {
+	names := albumArtworkSidecarNames(policy)
+	if len(names) == 0 || coverPath == "" || !fileExists(coverPath) {
+		return nil
+	}
+
+	albumArtworkSidecarMu.Lock()
+	defer albumArtworkSidecarMu.Unlock()
+
+	owner := albumArtworkSidecarOwners[albumDir]
+	if owner == nil {
+		owner = &albumArtworkSidecarOwner{albumKey: albumKey}
+		albumArtworkSidecarOwners[albumDir] = owner
+	} else if !owner.mixed && albumKey != "" && owner.albumKey != "" && owner.albumKey != albumKey {
+		owner.mixed = true
+		fmt.Printf("Folder holds more than one album, skipping album artwork: %s\n", albumDir)
+		for _, path := range owner.written {
+			os.Remove(path)
+		}
+		owner.written = nil
+	}
+	if owner.mixed {
+		return nil
+	}
+
+	var written []string
+	for _, name := range names {
+		target := filepath.Join(albumDir, name)
+		if info, err := os.Stat(target); err == nil && info.Size() > 0 {
+			continue
+		}
+		if err := ProcessCoverImageFile(coverPath, target, sidecarCoverImageOptions()); err != nil {
+			fmt.Printf("Warning: Failed to write %s: %v\n", name, err)
+			continue
+		}
+		written = append(written, target)
+	}
+	owner.written = append(owner.written, written...)
+	return written
+}
+
+func prepareTrackArtwork(audioPath, coverURL string, embedMaxQualityCover bool, lookup CoverLookup) (string, func()) {
+	if coverURL == "" {
+		return "", func() {}
+	}
+
+	policy := GetAlbumArtworkSidecarSetting()
+	opts := embeddedCoverImageOptions()
+	if opts.MaxDimension <= 0 && !embedMaxQualityCover && policy != albumArtworkSidecarNone {
+		opts.MaxDimension = defaultEmbeddedCoverSize
+	}
+
+	coverPath := audioPath + ".cover.jpg"
+	coverClient := NewCoverClient()
+	downloaded := false
+	if GetCoverSourceSetting() == coverSourceBest {
+		if err := coverClient.DownloadBestCoverToPath(coverURL, coverPath, lookup); err != nil {
+			fmt.Printf("Warning: Failed to resolve best cover, using Spotify: %v\n", err)
+		} else {
+			downloaded = true
+			fmt.Println("Cover downloaded")
+		}
+	}
+	if !downloaded {
+		if err := coverClient.DownloadCoverToPath(coverURL, coverPath, embedMaxQualityCover || policy != albumArtworkSidecarNone); err != nil {
+			fmt.Printf("Warning: Failed to download Spotify cover: %v\n", err)
+			return "", func() {}
+		}
+		fmt.Println("Spotify cover downloaded")
+	}
+
+	for _, path := range WriteAlbumArtworkSidecars(filepath.Dir(audioPath), coverPath, policy, coverURL) {
+		fmt.Printf("Album artwork saved to: %s\n", path)
+	}
+
+	embedPath := audioPath + ".embed.jpg"
+	if err := ProcessCoverImageFile(coverPath, embedPath, opts); err != nil {
+		fmt.Printf("Warning: Failed to process cover for embedding: %v\n", err)
+		embedPath = coverPath
+	}
+
+	return embedPath, func() {
+		os.Remove(coverPath)
+		if embedPath != coverPath {
+			os.Remove(embedPath)
+		}
+	}
+}
+
+func findArtworkFile(dirs []string, names []string) string {
+	for _, dir := range dirs {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Size() > 0 {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func collectExtraArtwork(audioPath, artistName string) []artworkPicture {
+	albumDir := filepath.Dir(audioPath)
+	var pictures []artworkPicture
+
+	if GetEmbedBackCoverSetting() {
+		if path := findArtworkFile([]string{albumDir}, []string{"back.jpg", "back.png", "Back.jpg", "Back.png"}); path != "" {
+			pictures = append(pictures, artworkPicture{Kind: artworkKindBack, Path: path})
+		}
+	}
+
+	if GetEmbedArtistPictureSetting() {
+		names := []string{"artist.jpg", "artist.png"}
+		for _, artist := range SplitArtistCredits(artistName, "") {
+			names = append(names, sanitizeFilename(artist)+"_Avatar.jpg")
+		}
+		if path := findArtworkFile([]string{albumDir, filepath.Dir(albumDir)}, names); path != "" {
+			pictures = append(pictures, artworkPicture{Kind: artworkKindArtist, Path: path})
+		}
+	}
+
+	return pictures
+}
+
+func embedExtraArtworkFLAC(f *flac.File, pictures []artworkPicture) {
+	for _, picture := range pictures {
+		data, err := os.ReadFile(picture.Path)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read %s picture: %v\n", picture.Kind, err)
+			continue
+		}
+
+		block, err := flacpicture.NewFromImageData(picture.flacType(), picture.description(), data, artworkMimeType(data))
+		if err != nil {
+			fmt.Printf("Warning: Failed to create %s picture block: %v\n", picture.Kind, err)
+			continue
+		}
+
+		for i := len(f.Meta) - 1; i >= 0; i-- {
+			if f.Meta[i].Type != flac.Picture {
+				continue
+			}
+			if existing, err := flacpicture.ParseFromMetaDataBlock(*f.Meta[i]); err == nil && existing.PictureType == picture.flacType() {
+				f.Meta = append(f.Meta[:i], f.Meta[i+1:]...)
+			}
+		}
+
+		pictureBlock := block.Marshal()
+		f.Meta = append(f.Meta, &pictureBlock)
+	}
+}
+
+func embedExtraArtworkMP3(tag *id3v2.Tag, pictures []artworkPicture) {
+	for _, picture := range pictures {
+		data, err := os.ReadFile(picture.Path)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read %s picture: %v\n", picture.Kind, err)
+			continue
+		}
+
+		tag.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    artworkMimeType(data),
+			PictureType: picture.id3Type(),
+			Description: picture.description(),
+			Picture:     data,
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	flacStreamInfoLength = 34
+	mp3FrameSearchLimit  = 64 * 1024
+)
+
+type nativeAudioInfo struct {
+	SampleRate    uint32
+	Channels      uint8
+	BitsPerSample uint8
+	TotalSamples  uint64
+	Duration      float64
+	Bitrate       int
+	MD5           string
+	Codec         string
+}
+
+var mp3SampleRates = [4][3]uint32{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+var mp3Bitrates = map[[2]int][16]int{
+	{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+	{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{2, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	{2, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	{2, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+func readNativeAudioInfo(path string) (*nativeAudioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var info *nativeAudioInfo
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		info, err = readFlacStreamInfo(f)
+	case ".mp3":
+		info, err = readMP3Info(f, stat.Size())
+	case ".m4a", ".mp4":
+		info, err = readMP4AudioInfo(f, stat.Size())
+	default:
+		return nil, fmt.Errorf("unsupported format for native probing: %s", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func skipID3v2(r io.ReaderAt) int64 {
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:3]) != "ID3" {
+		return 0
+	}
+	size := int64(header[6]&0x7F)<<21 | int64(header[7]&0x7F)<<14 | int64(header[8]&0x7F)<<7 | int64(header[9]&0x7F)
+	offset := 10 + size
+	if header[5]&0x10 != 0 {
+		offset += 10
+	}
+	return offset
+}
+
+func readFlacStreamInfo(r io.ReaderAt) (*nativeAudioInfo, error) {
+	offset := skipID3v2(r)
+
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("failed to read FLAC header: %w", err)
+	}
+	if string(header[:4]) != "fLaC" {
+		return nil, fmt.Errorf("not a FLAC file")
+	}
+	blockLength := int(header[5])<<16 | int(header[6])<<8 | int(header[7])
+	if header[4]&0x7F != 0 || blockLength < flacStreamInfoLength {
+		return nil, fmt.Errorf("first metadata block is not STREAMINFO")
+	}
+
+	data := make([]byte, flacStreamInfoLength)
+	if _, err := r.ReadAt(data, offset+8); err != nil {
+		return nil, fmt.Errorf("failed to read STREAMINFO: %w", err)
+	}
+
+	info := &nativeAudioInfo{
+		SampleRate:    uint32(data[10])<<12 | uint32(data[11])<<4 | uint32(data[12])>>4,
+		Channels:      ((data[12] >> 1) & 0x07) + 1,
+		BitsPerSample: ((data[12]&0x01)<<4 | data[13]>>4) + 1,
+		TotalSamples:  uint64(data[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(data[14:18])),
+	}
+	if info.SampleRate == 0 {
+		return nil, fmt.Errorf("invalid FLAC sample rate")
+	}
+	info.Duration = float64(info.TotalSamples) / float64(info.SampleRate)
+	if md5 := data[18:34]; !bytes.Equal(md5, make([]byte, 16)) {
+		info.MD5 = hex.EncodeToString(md5)
+	}
+	return info, nil
+}
+
+type mp3FrameHeader struct {
+	version     int
+	layer       int
+	bitrate     int
+	sampleRate  uint32
+	frameLength int
+}
+
+func parseMP3FrameHeader(b []byte) (mp3FrameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mp3FrameHeader{}, false
+	}
+
+	version := int(b[1]>>3) & 0x03
+	layer := 4 - int(b[1]>>1)&0x03
+	bitrateIndex := int(b[2] >> 4)
+	sampleRateIndex := int(b[2]>>2) & 0x03
+	if version == 1 || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3FrameHeader{}, false
+	}
+
+	table := 2
+	if version == 3 {
+		table = 1
+	}
+	header := mp3FrameHeader{
+		version:    version,
+		layer:      layer,
+		bitrate:    mp3Bitrates[[2]int{table, layer}][bitrateIndex] * 1000,
+		sampleRate: mp3SampleRates[version][sampleRateIndex],
+	}
+
+	padding := int(b[2]>>1) & 0x01
+	switch {
+	case layer == 1:
+		header.frameLength = (12*header.bitrate/int(header.sampleRate) + padding) * 4
+	case layer == 3 && version != 3:
+		header.frameLength = 72*header.bitrate/int(header.sampleRate) + padding
+	default:
+		header.frameLength = 144*header.bitrate/int(header.sampleRate) + padding
+	}
+	return header, header.frameLength > 4
+}
+
+func confirmMP3Frame(r io.ReaderAt, size, offset int64, header mp3FrameHeader) bool {
+	next := offset + int64(header.frameLength)
+	if next == size {
+		return true
+	}
+	buf := make([]byte, 4)
+	if next+4 > size {
+		return false
+	}
+	if _, err := r.ReadAt(buf, next); err != nil {
+		return false
+	}
+	following, ok := parseMP3FrameHeader(buf)
+	return ok && following.version == header.version && following.layer == header.layer && following.sampleRate == header.sampleRate
+}
+
+func readMP3Info(r io.ReaderAt, size int64) (*nativeAudioInfo, error) {
+	start := skipID3v2(r)
+	if start >= size {
+		return nil, fmt.Errorf("no MPEG audio frame found")
+	}
+
+	buf := make([]byte, min(int64(mp3FrameSearchLimit), size-start))
+	n, err := r.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read MP3 data: %w", err)
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		header, ok := parseMP3FrameHeader(buf[i : i+4])
+		if !ok || !confirmMP3Frame(r, size, start+int64(i), header) {
+			continue
+		}
+
+		version, layer := header.version, header.layer
+		info := &nativeAudioInfo{
+			SampleRate: header.sampleRate,
+			Channels:   2,
+			Bitrate:    header.bitrate,
+		}
+		mono := buf[i+3]>>6 == 3
+		if mono {
+			info.Channels = 1
+		}
+
+		samplesPerFrame := 1152
+		switch {
+		case layer == 1:
+			samplesPerFrame = 384
+		case layer == 3 && version != 3:
+			samplesPerFrame = 576
+		}
+
+		sideInfo := 32
+		switch {
+		case version == 3 && mono:
+			sideInfo = 17
+		case version != 3 && !mono:
+			sideInfo = 17
+		case version != 3:
+			sideInfo = 9
+		}
+
+		frames := uint32(0)
+		if xing := i + 4 + sideInfo; xing+12 <= len(buf) {
+			tag := string(buf[xing : xing+4])
+			if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(buf[xing+4:xing+8])&0x01 != 0 {
+				frames = binary.BigEndian.Uint32(buf[xing+8 : xing+12])
+			}
+		}
+		if vbri := i + 36; frames == 0 && vbri+18 <= len(buf) && string(buf[vbri:vbri+4]) == "VBRI" {
+			frames = binary.BigEndian.Uint32(buf[vbri+14 : vbri+18])
+		}
+
+		audioBytes := size - start - int64(i)
+		if frames > 0 {
+			info.TotalSamples = uint64(frames) * uint64(samplesPerFrame)
+			info.Duration = float64(info.TotalSamples) / float64(info.SampleRate)
+			info.Bitrate = int(float64(audioBytes*8) / info.Duration)
+		} else {
+			info.Duration = float64(audioBytes*8) / float64(info.Bitrate)
+			info.TotalSamples = uint64(info.Duration * float64(info.SampleRate))
+		}
+		return info, nil
+	}
+	return nil, fmt.Errorf("no MPEG audio frame found")
+}
+
+func readMP4AudioInfo(r io.ReaderAt, size int64) (*nativeAudioInfo, error) {
+	atoms, err := readMP4Atoms(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, atom := range atoms {
+		if atom.typ != "moov" {
+			continue
+		}
+		moov := make([]byte, atom.size-atom.headerSize)
+		if _, err := r.ReadAt(moov, atom.offset+atom.headerSize); err != nil {
+			return nil, fmt.Errorf("failed to read moov atom: %w", err)
+		}
+		boxes, err := parseMP4Boxes(moov)
+		if err != nil {
+			return nil, err
+		}
+		for _, box := range boxes {
+			if box.typ != "trak" {
+				continue
+			}
+			if info, err := readMP4TrackInfo(box.payload); err == nil {
+				if info.Bitrate == 0 && info.Duration > 0 {
+					var mdatSize int64
+					for _, other := range atoms {
+						if other.typ == "mdat" {
+							mdatSize += other.size - other.headerSize
+						}
+					}
+					info.Bitrate = int(float64(mdatSize*8) / info.Duration)
+				}
+				return info, nil
+			}
+		}
+		return nil, fmt.Errorf("no audio track found")
+	}
+	return nil, fmt.Errorf("no moov atom found")
+}
+
+func readMP4TrackInfo(trak []byte) (*nativeAudioInfo, error) {
+	mdia, err := mp4ChildPayload(trak, "mdia")
+	if err != nil {
+		return nil, err
+	}
+	hdlr, err := mp4ChildPayload(mdia, "hdlr")
+	if err != nil || len(hdlr) < 12 || string(hdlr[8:12]) != "soun" {
+		return nil, fmt.Errorf("not an audio track")
+	}
+
+	mdhd, err := mp4ChildPayload(mdia, "mdhd")
+	if err != nil || len(mdhd) < 24 {
+		return nil, fmt.Errorf("missing mdhd box")
+	}
+	var timescale uint32
+	var duration uint64
+	if mdhd[0] == 1 {
+		if len(mdhd) < 32 {
+			return nil, fmt.Errorf("truncated mdhd box")
+		}
+		timescale = binary.BigEndian.Uint32(mdhd[20:24])
+		duration = binary.BigEndian.Uint64(mdhd[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(mdhd[12:16])
+		duration = uint64(binary.BigEndian.Uint32(mdhd[16:20]))
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("invalid mdhd timescale")
+	}
+
+	stsd, err := mp4ChildPayload(mdia, "minf", "stbl", "stsd")
+	if err != nil || len(stsd) < 8 {
+		return nil, fmt.Errorf("missing stsd box")
+	}
+	entries, err := parseMP4Boxes(stsd[8:])
+	if err != nil || len(entries) == 0 || len(entries[0].payload) < 28 {
+		return nil, fmt.Errorf("missing audio sample entry")
+	}
+	entry := entries[0]
+
+	info := &nativeAudioInfo{
+		Channels:   uint8(binary.BigEndian.Uint16(entry.payload[16:18])),
+		SampleRate: uint32(binary.BigEndian.Uint16(entry.payload[24:26])),
+		Duration:   float64(duration) / float64(timescale),
+		Codec:      entry.typ,
+	}
+	if entry.typ == "alac" {
+		if config, err := mp4ChildPayload(entry.payload[28:], "alac"); err == nil && len(config) >= 28 {
+			info.BitsPerSample = config[9]
+			info.Channels = config[13]
+			info.SampleRate = binary.BigEndian.Uint32(config[24:28])
+		} else {
+			info.BitsPerSample = uint8(binary.BigEndian.Uint16(entry.payload[18:20]))
+		}
+	}
+	if info.SampleRate == 0 {
+		info.SampleRate = timescale
+	}
+	info.TotalSamples = uint64(info.Duration*float64(info.SampleRate) + 0.5)
+	if audioBytes, ok := mp4SampleBytes(mdia); ok && info.Duration > 0 {
+		info.Bitrate = int(float64(audioBytes*8) / info.Duration)
+	}
+	return info, nil
+}
+
+func mp4SampleBytes(mdia []byte) (uint64, bool) {
+	stsz, err := mp4ChildPayload(mdia, "minf", "stbl", "stsz")
+	if err != nil || len(stsz) < 12 {
+		return 0, false
+	}
+
+	sampleSize := uint64(binary.BigEndian.Uint32(stsz[4:8]))
+	sampleCount := uint64(binary.BigEndian.Uint32(stsz[8:12]))
+	if sampleSize != 0 {
+		return sampleSize * sampleCount, true
+	}
+	if uint64(len(stsz)-12) < sampleCount*4 {
+		return 0, false
+	}
+
+	var total uint64
+	for i := uint64(0); i < sampleCount; i++ {
+		total += uint64(binary.BigEndian.Uint32(stsz[12+i*4 : 16+i*4]))
+	}
+	return total, true
+}
+
+func mp4ChildPayload(payload []byte, path ...string) ([]byte, error) {
+	for _, typ := range path {
+		boxes, err := parseMP4Boxes(payload)
+		if err != nil {
+			return nil, err
+		}
+		idx := findMP4Box(boxes, typ)
+		if idx < 0 {
+			return nil, fmt.Errorf("missing %s box", typ)
+		}
+		payload = boxes[idx].payload
+	}
+	return payload, nil
+}
@@ -0,0 +1,211 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	audioJobStatusRunning   = "running"
+	audioJobStatusDone      = "done"
+	audioJobStatusFailed    = "failed"
+	audioJobStatusCancelled = "cancelled"
+)
+
+var errAudioJobCancelled = errors.New("cancelled")
+
+type AudioJobProgress struct {
+	JobID     string  `json:"job_id"`
+	File      string  `json:"file"`
+	Index     int     `json:"index"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+	Status    string  `json:"status"`
+	Completed int     `json:"completed"`
+}
+
+type audioJob struct {
+	id        string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	total     int
+	progress  func(AudioJobProgress)
+	mu        sync.Mutex
+	completed int
+}
+
+var (
+	audioJobsMu sync.Mutex
+	audioJobs   = map[string]*audioJob{}
+)
+
+func startAudioJob(id string, total int, progress func(AudioJobProgress)) *audioJob {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		id = fmt.Sprintf("job-%d", time.Now().UnixNano())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &audioJob{
+		id:       id,
+		ctx:      ctx,
+		cancel:   cancel,
+		total:    total,
+		progress: progress,
+	}
+
+	audioJobsMu.Lock()
+	if existing, ok := audioJobs[id]; ok {
+		existing.cancel()
+	}
+	audioJobs[id] = job
+	audioJobsMu.Unlock()
+	return job
+}
+
+func (j *audioJob) finish() {
+	audioJobsMu.Lock()
+	if audioJobs[j.id] == j {
+		delete(audioJobs, j.id)
+	}
+	audioJobsMu.Unlock()
+	j.cancel()
+}
+
+func CancelAudioJob(id string) bool {
+	audioJobsMu.Lock()
+	job, ok := audioJobs[strings.TrimSpace(id)]
+	audioJobsMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	fmt.Printf("[AudioJob] Cancelling job: %s\n", job.id)
+	job.cancel()
+	return true
+}
+
+func (j *audioJob) cancelled() bool {
+	return j.ctx.Err() != nil
+}
+
+func (j *audioJob) report(index int, file, status string, percent float64) {
+	if j.progress == nil && status == audioJobStatusRunning {
+		return
+	}
+
+	j.mu.Lock()
+	if status != audioJobStatusRunning {
+		j.completed++
+		percent = 100
+	}
+	update := AudioJobProgress{
+		JobID:     j.id,
+		File:      file,
+		Index:     index,
+		Total:     j.total,
+		Percent:   percent,
+		Status:    status,
+		Completed: j.completed,
+	}
+	j.mu.Unlock()
+
+	if j.progress != nil {
+		j.progress(update)
+	}
+}
+
+func (j *audioJob) run(work func(index int)) {
+	workers := min(GetAudioJobParallelismSetting(), j.total)
+	if workers <= 0 {
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				work(index)
+			}
+		}()
+	}
+
+	for i := 0; i < j.total; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func (j *audioJob) statusForError(err error) string {
+	if err == nil {
+		return audioJobStatusDone
+	}
+	if errors.Is(err, errAudioJobCancelled) || j.cancelled() {
+		return audioJobStatusCancelled
+	}
+	return audioJobStatusFailed
+}
+
+func parseFFmpegProgressSeconds(line string) (float64, bool) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+	if !ok || (key != "out_time_us" && key != "out_time_ms") {
+		return 0, false
+	}
+
+	us, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || us < 0 {
+		return 0, false
+	}
+	return float64(us) / 1e6, true
+}
+
+func runFFmpegWithProgress(ctx context.Context, ffmpegPath string, args []string, durationSeconds float64, onPercent func(float64)) ([]byte, error) {
+	if ctx.Err() != nil {
+		return nil, errAudioJobCancelled
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, append([]string{"-nostats", "-progress", "pipe:1"}, args...)...)
+	setHideWindow(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	lastPercent := -1.0
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		seconds, ok := parseFFmpegProgressSeconds(scanner.Text())
+		if !ok || durationSeconds <= 0 || onPercent == nil {
+			continue
+		}
+		percent := min(seconds/durationSeconds*100, 100)
+		if percent-lastPercent >= 1 {
+			lastPercent = percent
+			onPercent(percent)
+		}
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return stderr.Bytes(), errAudioJobCancelled
+	}
+	return stderr.Bytes(), err
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 )
 
 const legacyTidalAPICacheFile = "tidal-api-urls.json"
@@ -247,3 +249,332 @@ func GetLinkResolverAllowFallback() bool {
 
 	return allowFallback
 }
+
+func GetFeaturedArtistPolicySetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return featuredArtistPolicyKeep
+	}
+
+	policy, _ := settings["featuredArtistPolicy"].(string)
+	return normalizeFeaturedArtistPolicy(policy)
+}
+
+func GetMultiValueArtistTagsSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return true
+	}
+
+	enabled, ok := settings["multiValueArtistTags"].(bool)
+	if !ok {
+		return true
+	}
+
+	return enabled
+}
+
+func GetArtistSortTagsSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return true
+	}
+
+	enabled, ok := settings["artistSortTags"].(bool)
+	if !ok {
+		return true
+	}
+
+	return enabled
+}
+
+func GetCleanTitlesSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return false
+	}
+
+	enabled, _ := settings["cleanTitles"].(bool)
+	return enabled
+}
+
+func GetGenreWhitelistSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return true
+	}
+
+	enabled, ok := settings["genreWhitelist"].(bool)
+	if !ok {
+		return true
+	}
+
+	return enabled
+}
+
+func GetGenreCollapseToParentSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return false
+	}
+
+	enabled, _ := settings["genreCollapseToParent"].(bool)
+	return enabled
+}
+
+func GetGenreSourcesSetting() []string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return strings.Split(defaultGenreSources, "-")
+	}
+
+	return sanitizeGenreSourcesValue(settings["genreSources"])
+}
+
+func GetGenreSourceModeSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return genreSourceModeFallback
+	}
+
+	mode, _ := settings["genreSourceMode"].(string)
+	switch strings.TrimSpace(strings.ToLower(mode)) {
+	case genreSourceModeMerge:
+		return genreSourceModeMerge
+	default:
+		return genreSourceModeFallback
+	}
+}
+
+func GetExplicitPreferenceSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return explicitPreferenceEither
+	}
+
+	preference, _ := settings["explicitPreference"].(string)
+	return normalizeExplicitPreference(preference)
+}
+
+func GetLyricsProvidersSetting() []string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return strings.Split(defaultLyricsProviders, "-")
+	}
+
+	return sanitizeLyricsProvidersValue(settings["lyricsProviders"])
+}
+
+func GetLyricsProviderTimeoutSetting(provider string) time.Duration {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return defaultLyricsProviderTimeout
+	}
+
+	timeouts, _ := settings["lyricsProviderTimeouts"].(map[string]interface{})
+	seconds, _ := timeouts[provider].(float64)
+	if seconds <= 0 {
+		return defaultLyricsProviderTimeout
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func GetLyricsLocalDirSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return ""
+	}
+
+	dir, _ := settings["lyricsLocalDir"].(string)
+	return strings.TrimSpace(dir)
+}
+
+func GetLyricsFormatSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return lyricsFormatLRC
+	}
+
+	format, _ := settings["lyricsFormat"].(string)
+	return normalizeLyricsFormat(format)
+}
+
+func GetLyricsModeSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return lyricsModeEmbed
+	}
+
+	mode, _ := settings["lyricsMode"].(string)
+	return normalizeLyricsMode(mode)
+}
+
+func GetLyricsLanguageSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return ""
+	}
+
+	code, _ := settings["lyricsLanguage"].(string)
+	return strings.TrimSpace(code)
+}
+
+func GetLyricsInstrumentalMarkerSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return defaultInstrumentalLyricsMarker
+	}
+
+	marker, ok := settings["lyricsInstrumentalMarker"].(string)
+	if !ok {
+		return defaultInstrumentalLyricsMarker
+	}
+	return strings.TrimSpace(marker)
+}
+
+func GetAlbumArtworkSidecarSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return albumArtworkSidecarNone
+	}
+
+	policy, _ := settings["albumArtworkSidecar"].(string)
+	return normalizeAlbumArtworkSidecar(policy)
+}
+
+func GetEmbeddedCoverMaxSizeSetting() int {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return 0
+	}
+
+	size, _ := settings["embeddedCoverMaxSize"].(float64)
+	if size <= 0 {
+		return 0
+	}
+	return int(size)
+}
+
+func GetEmbedArtistPictureSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return false
+	}
+
+	enabled, _ := settings["embedArtistPicture"].(bool)
+	return enabled
+}
+
+func GetEmbedBackCoverSetting() bool {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return false
+	}
+
+	enabled, _ := settings["embedBackCover"].(bool)
+	return enabled
+}
+
+func GetCoverJPEGQualitySetting() int {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return defaultCoverJPEGQuality
+	}
+
+	quality, _ := settings["coverJpegQuality"].(float64)
+	if quality < minCoverJPEGQuality || quality > 100 {
+		return defaultCoverJPEGQuality
+	}
+	return int(quality)
+}
+
+func GetEmbeddedCoverMaxKBSetting() int {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return 0
+	}
+
+	size, _ := settings["embeddedCoverMaxKB"].(float64)
+	if size <= 0 {
+		return 0
+	}
+	return int(size)
+}
+
+func GetSidecarCoverMaxKBSetting() int {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return 0
+	}
+
+	size, _ := settings["sidecarCoverMaxKB"].(float64)
+	if size <= 0 {
+		return 0
+	}
+	return int(size)
+}
+
+func GetCoverSourceSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return coverSourceSpotify
+	}
+
+	source, _ := settings["coverSource"].(string)
+	return normalizeCoverSource(source)
+}
+
+func GetConversionPresetsSetting() []ConversionPreset {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return defaultConversionPresets()
+	}
+
+	return sanitizeConversionPresetsValue(settings["conversionPresets"])
+}
+
+func GetAudioJobParallelismSetting() int {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return runtime.NumCPU()
+	}
+
+	workers, _ := settings["audioJobParallelism"].(float64)
+	if workers < 1 {
+		return runtime.NumCPU()
+	}
+	return int(workers)
+}
+
+func GetFFmpegVersionSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return ""
+	}
+
+	version, _ := settings["ffmpegVersion"].(string)
+	return normalizeFFmpegVersion(version)
+}
+
+func GetPipelineProfilesSetting() []PipelineProfile {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return nil
+	}
+
+	return sanitizePipelineProfilesValue(settings["pipelineProfiles"])
+}
+
+func GetPipelineProfileSetting() string {
+	settings, err := LoadConfigSettings()
+	if err != nil || settings == nil {
+		return defaultPipelineProfileName
+	}
+
+	profile, _ := settings["pipelineProfile"].(string)
+	if strings.TrimSpace(profile) == "" {
+		return defaultPipelineProfileName
+	}
+	return strings.TrimSpace(profile)
+}
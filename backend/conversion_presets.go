@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	conversionModeCBR  = "cbr"
+	conversionModeVBR  = "vbr"
+	conversionModeCVBR = "cvbr"
+
+	defaultConversionBitrate     = "320k"
+	defaultOpusConversionBitrate = "128k"
+	defaultFLACCompressionLevel  = 8
+	maxLAMEVBRQuality            = 9
+	defaultAACVBRQuality         = 2
+	defaultVorbisVBRQuality      = 5
+)
+
+var conversionFormats = map[string]bool{
+	"mp3":  true,
+	"m4a":  true,
+	"opus": true,
+	"ogg":  true,
+	"flac": true,
+	"wav":  true,
+	"aiff": true,
+}
+
+type ConversionPreset struct {
+	Name          string   `json:"name"`
+	Format        string   `json:"format"`
+	Codec         string   `json:"codec,omitempty"`
+	Mode          string   `json:"mode,omitempty"`
+	Bitrate       string   `json:"bitrate,omitempty"`
+	Quality       *float64 `json:"quality,omitempty"`
+	MaxSampleRate int      `json:"maxSampleRate,omitempty"`
+	MaxBitDepth   int      `json:"maxBitDepth,omitempty"`
+	Channels      int      `json:"channels,omitempty"`
+}
+
+func defaultConversionPresets() []ConversionPreset {
+	return []ConversionPreset{
+		{Name: "Phone Opus 128", Format: "opus", Mode: conversionModeVBR, Bitrate: "128k", Channels: 2},
+		{Name: "MP3 V0", Format: "mp3", Mode: conversionModeVBR, Quality: new(0.0)},
+		{Name: "ALAC archive", Format: "m4a", Codec: "alac"},
+	}
+}
+
+func normalizeConversionMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case conversionModeVBR:
+		return conversionModeVBR
+	case conversionModeCVBR:
+		return conversionModeCVBR
+	case conversionModeCBR:
+		return conversionModeCBR
+	default:
+		return ""
+	}
+}
+
+func normalizeConversionPreset(preset ConversionPreset) (ConversionPreset, bool) {
+	preset.Name = strings.TrimSpace(preset.Name)
+	preset.Format = strings.ToLower(strings.TrimSpace(preset.Format))
+	preset.Codec = strings.ToLower(strings.TrimSpace(preset.Codec))
+	preset.Bitrate = strings.TrimSpace(preset.Bitrate)
+	preset.Mode = normalizeConversionMode(preset.Mode)
+	if preset.Format == "m4a" && preset.Codec != "alac" {
+		preset.Codec = "aac"
+	}
+	if preset.Format != "m4a" {
+		preset.Codec = ""
+	}
+	preset.MaxSampleRate = max(preset.MaxSampleRate, 0)
+	preset.MaxBitDepth = max(preset.MaxBitDepth, 0)
+	preset.Channels = max(preset.Channels, 0)
+
+	return preset, preset.Name != "" && conversionFormats[preset.Format]
+}
+
+func sanitizeConversionPresetsValue(value interface{}) []ConversionPreset {
+	if value == nil {
+		return defaultConversionPresets()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return defaultConversionPresets()
+	}
+	var raw []ConversionPreset
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return defaultConversionPresets()
+	}
+
+	defaults := defaultConversionPresets()
+	presets := make([]ConversionPreset, 0, len(raw)+len(defaults))
+	seen := map[string]bool{}
+	for _, preset := range append(raw, defaults...) {
+		preset, ok := normalizeConversionPreset(preset)
+		if !ok || seen[strings.ToLower(preset.Name)] {
+			continue
+		}
+		seen[strings.ToLower(preset.Name)] = true
+		presets = append(presets, preset)
+	}
+	return presets
+}
+
+func FindConversionPreset(name string) (ConversionPreset, bool) {
+	name = strings.TrimSpace(name)
+	for _, preset := range GetConversionPresetsSetting() {
+		if strings.EqualFold(preset.Name, name) {
+			return preset, true
+		}
+	}
+	return ConversionPreset{}, false
+}
+
+func (p ConversionPreset) bitrateOrDefault() string {
+	if p.Bitrate != "" {
+		return p.Bitrate
+	}
+	if p.Format == "opus" {
+		return defaultOpusConversionBitrate
+	}
+	return defaultConversionBitrate
+}
+
+func (p ConversionPreset) qualityOrDefault(fallback float64) float64 {
+	if p.Quality != nil {
+		return *p.Quality
+	}
+	return fallback
+}
+
+func (p ConversionPreset) ffmpegArgs(source FlacInfo) []string {
+	sourceBits := int(source.BitsPerSample)
+	bitDepthCapped := p.MaxBitDepth > 0 && sourceBits > p.MaxBitDepth
+	bits := sourceBits
+	if bitDepthCapped {
+		bits = p.MaxBitDepth
+	}
+
+	var args []string
+	switch p.Format {
+	case "mp3":
+		args = append(args, "-codec:a", "libmp3lame")
+		if p.Mode == conversionModeVBR {
+			quality := min(max(int(p.qualityOrDefault(0)), 0), maxLAMEVBRQuality)
+			args = append(args, "-q:a", strconv.Itoa(quality))
+		} else {
+			args = append(args, "-b:a", p.bitrateOrDefault())
+		}
+		args = append(args, "-id3v2_version", "3")
+	case "m4a":
+		if p.Codec == "alac" {
+			args = append(args, "-codec:a", "alac")
+			if bitDepthCapped && bits <= 16 {
+				args = append(args, "-sample_fmt", "s16p")
+			}
+		} else if p.Mode == conversionModeVBR {
+			quality := p.qualityOrDefault(defaultAACVBRQuality)
+			if quality <= 0 {
+				quality = defaultAACVBRQuality
+			}
+			args = append(args, "-codec:a", "aac", "-q:a", strconv.FormatFloat(quality, 'f', -1, 64))
+		} else {
+			args = append(args, "-codec:a", "aac", "-b:a", p.bitrateOrDefault())
+		}
+	case "opus":
+		vbr := "on"
+		switch p.Mode {
+		case conversionModeCBR:
+			vbr = "off"
+		case conversionModeCVBR:
+			vbr = "constrained"
+		}
+		args = append(args, "-codec:a", "libopus", "-b:a", p.bitrateOrDefault(), "-vbr", vbr)
+	case "ogg":
+		args = append(args, "-codec:a", "libvorbis")
+		if p.Mode == conversionModeVBR {
+			args = append(args, "-q:a", strconv.FormatFloat(p.qualityOrDefault(defaultVorbisVBRQuality), 'f', -1, 64))
+		} else {
+			args = append(args, "-b:a", p.bitrateOrDefault())
+		}
+	case "flac":
+		level := min(max(int(p.qualityOrDefault(defaultFLACCompressionLevel)), 0), maxFLACCompressionLevel)
+		args = append(args, "-codec:a", "flac", "-compression_level", strconv.Itoa(level))
+		if bitDepthCapped && bits <= 16 {
+			args = append(args, "-sample_fmt", "s16")
+		}
+	case "wav", "aiff":
+		codec := "pcm_s16"
+		if bits > 16 {
+			codec = "pcm_s24"
+		}
+		if p.Format == "aiff" {
+			codec += "be"
+		} else {
+			codec += "le"
+		}
+		args = append(args, "-codec:a", codec)
+	}
+
+	if p.MaxSampleRate > 0 && p.Format != "opus" && int(source.SampleRate) > p.MaxSampleRate {
+		args = append(args, "-ar", strconv.Itoa(p.MaxSampleRate))
+	}
+	if p.Channels > 0 {
+		args = append(args, "-ac", strconv.Itoa(p.Channels))
+	}
+
+	return append(args, "-map", "0:a")
+}
@@ -295,17 +295,21 @@ func (c *CoverClient) DownloadCover(req CoverDownloadRequest) (*CoverDownloadRes
 		}, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
-	file, err := os.Create(filePath)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return &CoverDownloadResponse{
 			Success: false,
-			Error:   fmt.Sprintf("failed to create file: %v", err),
+			Error:   fmt.Sprintf("failed to read cover: %v", err),
 		}, err
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
+	if processed, err := ProcessCoverImage(data, sidecarCoverImageOptions()); err != nil {
+		fmt.Printf("Warning: Failed to process cover image: %v\n", err)
+	} else {
+		data = processed
+	}
+
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return &CoverDownloadResponse{
 			Success: false,
 			Error:   fmt.Sprintf("failed to write cover file: %v", err),
@@ -0,0 +1,378 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	coverAuditStatusOK       = "ok"
+	coverAuditStatusMissing  = "missing"
+	coverAuditStatusLowRes   = "low_res"
+	coverAuditStatusMismatch = "mismatch"
+
+	coverAuditActionNone         = "none"
+	coverAuditActionWouldReplace = "would_replace"
+	coverAuditActionReplaced     = "replaced"
+	coverAuditActionNoSource     = "no_source"
+	coverAuditActionFailed       = "failed"
+
+	defaultCoverAuditMinSize = 600
+)
+
+type CoverAuditRequest struct {
+	FolderPath string `json:"folder_path"`
+	MinSize    int    `json:"min_size,omitempty"`
+	Apply      bool   `json:"apply,omitempty"`
+	Online     bool   `json:"online,omitempty"`
+}
+
+type CoverAuditFileResult struct {
+	File         string `json:"file"`
+	Album        string `json:"album,omitempty"`
+	Status       string `json:"status"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Action       string `json:"action"`
+	Source       string `json:"source,omitempty"`
+	TargetWidth  int    `json:"target_width,omitempty"`
+	TargetHeight int    `json:"target_height,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type CoverAuditSummary struct {
+	Total    int                    `json:"total"`
+	OK       int                    `json:"ok"`
+	Missing  int                    `json:"missing"`
+	LowRes   int                    `json:"low_res"`
+	Mismatch int                    `json:"mismatch"`
+	Replaced int                    `json:"replaced"`
+	Failed   int                    `json:"failed"`
+	DryRun   bool                   `json:"dry_run"`
+	Results  []CoverAuditFileResult `json:"results"`
+}
+
+type CoverAuditProgress struct {
+	Current int                  `json:"current"`
+	Total   int                  `json:"total"`
+	Result  CoverAuditFileResult `json:"result"`
+}
+
+type coverAuditFile struct {
+	path       string
+	album      string
+	spotifyURL string
+	artist     string
+	title      string
+	width      int
+	height     int
+	hash       string
+}
+
+type coverAuditImage struct {
+	source string
+	width  int
+	height int
+	hash   string
+	data   []byte
+}
+
+func newCoverAuditImage(source string, data []byte) *coverAuditImage {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return &coverAuditImage{
+		source: source,
+		width:  config.Width,
+		height: config.Height,
+		hash:   hex.EncodeToString(sum[:]),
+		data:   data,
+	}
+}
+
+func (img *coverAuditImage) pixels() int {
+	if img == nil {
+		return 0
+	}
+	return img.width * img.height
+}
+
+func inspectEmbeddedCover(path string) (*coverAuditImage, error) {
+	coverPath, err := ExtractCoverArt(path)
+	if err != nil || coverPath == "" {
+		return nil, err
+	}
+	defer os.Remove(coverPath)
+
+	data, err := os.ReadFile(coverPath)
+	if err != nil {
+		return nil, err
+	}
+	img := newCoverAuditImage("embedded", data)
+	if img == nil {
+		return nil, fmt.Errorf("embedded cover could not be decoded")
+	}
+	return img, nil
+}
+
+func fetchSpotifyTrackCoverURL(spotifyURL string) (string, error) {
+	trackID, err := extractSpotifyTrackID(spotifyURL)
+	if err != nil {
+		return "", err
+	}
+
+	client := NewSpotifyMetadataClient()
+	raw, err := client.fetchTrack(context.Background(), trackID)
+	if err != nil {
+		return "", err
+	}
+	return client.formatTrackData(raw).Track.Images, nil
+}
+
+func resolveOnlineAuditCover(files []*coverAuditFile) *coverAuditImage {
+	for _, file := range files {
+		if file.spotifyURL == "" {
+			continue
+		}
+
+		coverURL, err := fetchSpotifyTrackCoverURL(file.spotifyURL)
+		if err != nil || coverURL == "" {
+			continue
+		}
+
+		best, err := NewCoverClient().ResolveBestCover(coverURL, CoverLookup{
+			SpotifyURL: file.spotifyURL,
+			TrackName:  file.title,
+			ArtistName: file.artist,
+			AlbumName:  file.album,
+		})
+		if err != nil {
+			continue
+		}
+		return newCoverAuditImage(best.Source, best.data)
+	}
+	return nil
+}
+
+func findSidecarAuditCover(dir string) *coverAuditImage {
+	var best *coverAuditImage
+	for _, name := range coverSidecarNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if img := newCoverAuditImage(name, data); img != nil && img.pixels() > best.pixels() {
+			best = img
+		}
+	}
+	return best
+}
+
+func (c *CoverClient) auditCoverGroup(files []*coverAuditFile, embedded *coverAuditImage, dir string, req CoverAuditRequest, minSize int) []CoverAuditFileResult {
+	var best *coverAuditImage
+	hashes := map[string]int{}
+	for _, file := range files {
+		if file.hash != "" {
+			hashes[file.hash]++
+		}
+	}
+
+	needsWork := len(hashes) > 1
+	for _, file := range files {
+		if file.hash == "" || max(file.width, file.height) < minSize {
+			needsWork = true
+		}
+	}
+
+	if needsWork {
+		best = embedded
+		if sidecar := findSidecarAuditCover(dir); sidecar.pixels() > best.pixels() {
+			best = sidecar
+		}
+		if req.Online {
+			if online := resolveOnlineAuditCover(files); online.pixels() > best.pixels() {
+				best = online
+			}
+		}
+	}
+
+	if best != nil && max(best.width, best.height) < minSize && len(hashes) <= 1 {
+		best = nil
+	}
+
+	var tmpPath string
+	if best != nil && req.Apply {
+		if processed, err := ProcessCoverImage(best.data, embeddedCoverImageOptions()); err == nil {
+			best.data = processed
+		}
+		if tmpFile, err := os.CreateTemp("", "spotiflac-cover-audit-*.jpg"); err == nil {
+			tmpPath = tmpFile.Name()
+			_, err = tmpFile.Write(best.data)
+			tmpFile.Close()
+			if err != nil {
+				os.Remove(tmpPath)
+				tmpPath = ""
+			}
+		}
+		defer func() {
+			if tmpPath != "" {
+				os.Remove(tmpPath)
+			}
+		}()
+	}
+
+	results := make([]CoverAuditFileResult, 0, len(files))
+	for _, file := range files {
+		result := CoverAuditFileResult{
+			File:   file.path,
+			Album:  file.album,
+			Status: coverAuditStatusOK,
+			Width:  file.width,
+			Height: file.height,
+			Action: coverAuditActionNone,
+		}
+
+		switch {
+		case file.hash == "":
+			result.Status = coverAuditStatusMissing
+		case max(file.width, file.height) < minSize:
+			result.Status = coverAuditStatusLowRes
+		case len(hashes) > 1 && best != nil && file.hash != best.hash:
+			result.Status = coverAuditStatusMismatch
+		}
+
+		if result.Status == coverAuditStatusOK {
+			results = append(results, result)
+			continue
+		}
+
+		if best == nil || (best.hash == file.hash) {
+			result.Action = coverAuditActionNoSource
+			results = append(results, result)
+			continue
+		}
+
+		result.Source = best.source
+		result.TargetWidth = best.width
+		result.TargetHeight = best.height
+
+		switch {
+		case !req.Apply:
+			result.Action = coverAuditActionWouldReplace
+		case tmpPath == "":
+			result.Action = coverAuditActionFailed
+			result.Error = "failed to prepare replacement cover"
+		default:
+			if err := EmbedCoverArtOnly(file.path, tmpPath); err != nil {
+				result.Action = coverAuditActionFailed
+				result.Error = err.Error()
+			} else {
+				result.Action = coverAuditActionReplaced
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (s *CoverAuditSummary) add(result CoverAuditFileResult) {
+	switch result.Status {
+	case coverAuditStatusOK:
+		s.OK++
+	case coverAuditStatusMissing:
+		s.Missing++
+	case coverAuditStatusLowRes:
+		s.LowRes++
+	case coverAuditStatusMismatch:
+		s.Mismatch++
+	}
+	switch result.Action {
+	case coverAuditActionReplaced:
+		s.Replaced++
+	case coverAuditActionFailed:
+		s.Failed++
+	}
+	s.Results = append(s.Results, result)
+}
+
+func (c *CoverClient) RunCoverAudit(req CoverAuditRequest, progress func(CoverAuditProgress)) (*CoverAuditSummary, error) {
+	folder := strings.TrimSpace(req.FolderPath)
+	if folder == "" {
+		return nil, fmt.Errorf("folder path is required")
+	}
+
+	files, err := ListAudioFiles(NormalizePath(folder))
+	if err != nil {
+		return nil, err
+	}
+
+	minSize := req.MinSize
+	if minSize <= 0 {
+		minSize = defaultCoverAuditMinSize
+	}
+
+	groups := map[string][]*coverAuditFile{}
+	embedded := map[string]*coverAuditImage{}
+	for _, file := range files {
+		entry := &coverAuditFile{path: file.Path}
+		if metadata, err := ExtractFullMetadataFromFile(file.Path); err == nil {
+			entry.album = strings.TrimSpace(metadata.Album)
+			entry.artist = strings.TrimSpace(metadata.Artist)
+			entry.title = strings.TrimSpace(metadata.Title)
+			for _, value := range []string{metadata.URL, metadata.Comment} {
+				if _, err := extractSpotifyTrackID(value); err == nil {
+					entry.spotifyURL = value
+					break
+				}
+			}
+		}
+		key := filepath.Dir(file.Path) + "\x00" + strings.ToLower(entry.album)
+		if img, err := inspectEmbeddedCover(file.Path); err == nil && img != nil {
+			entry.width, entry.height, entry.hash = img.width, img.height, img.hash
+			if img.pixels() > embedded[key].pixels() {
+				img.source = "album (" + filepath.Base(file.Path) + ")"
+				embedded[key] = img
+			}
+		}
+		groups[key] = append(groups[key], entry)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	summary := &CoverAuditSummary{
+		Total:   len(files),
+		DryRun:  !req.Apply,
+		Results: make([]CoverAuditFileResult, 0, len(files)),
+	}
+
+	current := 0
+	for _, key := range keys {
+		dir, _, _ := strings.Cut(key, "\x00")
+		for _, result := range c.auditCoverGroup(groups[key], embedded[key], dir, req, minSize) {
+			current++
+			summary.add(result)
+			if progress != nil {
+				progress(CoverAuditProgress{Current: current, Total: len(files), Result: result})
+			}
+		}
+	}
+
+	fmt.Printf("[CoverAudit] Done: %d ok, %d missing, %d low-res, %d mismatched, %d replaced, %d failed\n",
+		summary.OK, summary.Missing, summary.LowRes, summary.Mismatch, summary.Replaced, summary.Failed)
+	return summary, nil
+}
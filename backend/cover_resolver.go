@@ -0,0 +1,319 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	coverSourceSpotify = "spotify"
+	coverSourceBest    = "best"
+
+	maxCoverDownloadBytes = 25 << 20
+	maxCoverHeaderBytes   = 64 << 10
+	maxCoverAspectSkew    = 1.1
+)
+
+var (
+	qobuzCoverSizePattern = regexp.MustCompile(`_(\d+|max|org)\.jpg$`)
+	resolvedCoverCache    sync.Map
+)
+
+type CoverLookup struct {
+	SpotifyURL string
+	TrackName  string
+	ArtistName string
+	AlbumName  string
+}
+
+type CoverCandidate struct {
+	Source string `json:"source"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	data   []byte
+}
+
+type tidalTrackInfoResponse struct {
+	Data struct {
+		Album struct {
+			Title string `json:"title"`
+			Cover string `json:"cover"`
+		} `json:"album"`
+	} `json:"data"`
+	Album struct {
+		Title string `json:"title"`
+		Cover string `json:"cover"`
+	} `json:"album"`
+}
+
+func normalizeCoverSource(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), coverSourceBest) {
+		return coverSourceBest
+	}
+	return coverSourceSpotify
+}
+
+func coverAlbumMatches(candidateAlbum, albumName string) bool {
+	if strings.TrimSpace(candidateAlbum) == "" || strings.TrimSpace(albumName) == "" {
+		return true
+	}
+	candidate := normalizeQobuzSearchValue(ParseTitleVersion(candidateAlbum).Title)
+	expected := normalizeQobuzSearchValue(ParseTitleVersion(albumName).Title)
+	return candidate == expected || strings.Contains(candidate, expected) || strings.Contains(expected, candidate)
+}
+
+func buildTidalCoverURL(coverID, size string) string {
+	return fmt.Sprintf("https://resources.tidal.com/images/%s/%s.jpg", strings.ReplaceAll(coverID, "-", "/"), size)
+}
+
+func buildQobuzMaxCoverURL(imageURL string) string {
+	return qobuzCoverSizePattern.ReplaceAllString(imageURL, "_max.jpg")
+}
+
+func (c *CoverClient) fetchCoverCandidate(source, imageURL string) (*CoverCandidate, error) {
+	req, err := NewRequestWithDefaultHeaders(http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverDownloadBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return &CoverCandidate{
+		Source: source,
+		URL:    imageURL,
+		Width:  config.Width,
+		Height: config.Height,
+		data:   data,
+	}, nil
+}
+
+func (c *CoverClient) probeCoverCandidate(source, imageURL string) (*CoverCandidate, error) {
+	req, err := NewRequestWithDefaultHeaders(http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxCoverHeaderBytes-1))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverHeaderBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return c.fetchCoverCandidate(source, imageURL)
+	}
+
+	return &CoverCandidate{
+		Source: source,
+		URL:    imageURL,
+		Width:  config.Width,
+		Height: config.Height,
+	}, nil
+}
+
+func resolvedCoverCacheKey(spotifyCoverURL string, lookup CoverLookup) string {
+	album := normalizeQobuzSearchValue(lookup.AlbumName)
+	if spotifyCoverURL != "" {
+		return spotifyCoverURL + "\x00" + album
+	}
+	return normalizeQobuzSearchValue(lookup.ArtistName) + "\x00" + album
+}
+
+func (c *CoverClient) tidalCoverURLs(tidalURL, albumName string) []string {
+	if tidalURL == "" {
+		return nil
+	}
+
+	apis, err := getConfiguredTidalAPIAttemptList()
+	if err != nil || len(apis) == 0 {
+		return nil
+	}
+
+	downloader := NewTidalDownloader(apis[0])
+	trackID, err := downloader.GetTrackIDFromURL(tidalURL)
+	if err != nil {
+		return nil
+	}
+
+	req, err := NewRequestWithDefaultHeaders(http.MethodGet, fmt.Sprintf("%s/info/?id=%d", downloader.apiURL, trackID), nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var info tidalTrackInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil
+	}
+
+	album := info.Data.Album
+	if album.Cover == "" {
+		album = info.Album
+	}
+	if album.Cover == "" || !coverAlbumMatches(album.Title, albumName) {
+		return nil
+	}
+
+	return []string{buildTidalCoverURL(album.Cover, "origin"), buildTidalCoverURL(album.Cover, "1280x1280")}
+}
+
+func qobuzCoverURLs(isrc string, lookup CoverLookup) []string {
+	if isrc == "" {
+		return nil
+	}
+
+	track, err := NewQobuzDownloader().searchByISRC(isrc, lookup.TrackName, lookup.ArtistName, lookup.AlbumName)
+	if err != nil || track == nil {
+		return nil
+	}
+
+	imageURL := firstNonEmptyQobuzValue(track.Album.Image.Large, track.Album.Image.Small, track.Album.Image.Thumbnail)
+	if imageURL == "" || !coverAlbumMatches(track.Album.Title, lookup.AlbumName) {
+		return nil
+	}
+
+	return []string{buildQobuzMaxCoverURL(imageURL), imageURL}
+}
+
+func (c *CoverClient) ResolveBestCover(spotifyCoverURL string, lookup CoverLookup) (*CoverCandidate, error) {
+	cacheKey := resolvedCoverCacheKey(spotifyCoverURL, lookup)
+	if cached, ok := resolvedCoverCache.Load(cacheKey); ok {
+		resolved := cached.(CoverCandidate)
+		if resolved.URL == "" {
+			return nil, fmt.Errorf("no cover found")
+		}
+		if best, err := c.fetchCoverCandidate(resolved.Source, resolved.URL); err == nil {
+			return best, nil
+		}
+		resolvedCoverCache.Delete(cacheKey)
+	}
+
+	sources := map[string][]string{}
+	if spotifyCoverURL != "" {
+		sources["Spotify"] = []string{c.getMaxResolutionURL(spotifyCoverURL), convertSmallToMedium(spotifyCoverURL)}
+	}
+
+	if trackID, err := extractSpotifyTrackID(lookup.SpotifyURL); err == nil {
+		links, _ := NewSongLinkClient().resolveSpotifyTrackLinks(trackID, "")
+		if links != nil {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if urls := c.tidalCoverURLs(links.TidalURL, lookup.AlbumName); len(urls) > 0 {
+					mu.Lock()
+					sources["Tidal"] = urls
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if urls := qobuzCoverURLs(links.ISRC, lookup); len(urls) > 0 {
+					mu.Lock()
+					sources["Qobuz"] = urls
+					mu.Unlock()
+				}
+			}()
+			wg.Wait()
+		}
+	}
+
+	var best *CoverCandidate
+	for _, source := range []string{"Spotify", "Qobuz", "Tidal"} {
+		for _, imageURL := range sources[source] {
+			candidate, err := c.probeCoverCandidate(source, imageURL)
+			if err != nil {
+				continue
+			}
+
+			if candidate.Width > 0 && candidate.Height > 0 {
+				ratio := float64(candidate.Width) / float64(candidate.Height)
+				if ratio > maxCoverAspectSkew || ratio < 1/maxCoverAspectSkew {
+					break
+				}
+			}
+
+			fmt.Printf("[CoverResolver] %s: %dx%d\n", source, candidate.Width, candidate.Height)
+			if best == nil || candidate.Width*candidate.Height > best.Width*best.Height {
+				best = candidate
+			}
+			break
+		}
+	}
+
+	if best == nil {
+		resolvedCoverCache.Store(cacheKey, CoverCandidate{})
+		return nil, fmt.Errorf("no cover found")
+	}
+
+	if best.data == nil {
+		full, err := c.fetchCoverCandidate(best.Source, best.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s cover: %w", best.Source, err)
+		}
+		best = full
+	}
+
+	resolvedCoverCache.Store(cacheKey, CoverCandidate{Source: best.Source, URL: best.URL, Width: best.Width, Height: best.Height})
+	fmt.Printf("[CoverResolver] Using %s cover (%dx%d)\n", best.Source, best.Width, best.Height)
+	return best, nil
+}
+
+func (c *CoverClient) DownloadBestCoverToPath(spotifyCoverURL, outputPath string, lookup CoverLookup) error {
+	best, err := c.ResolveBestCover(spotifyCoverURL, lookup)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, best.data, 0644); err != nil {
+		return fmt.Errorf("failed to write cover file: %w", err)
+	}
+	return nil
+}
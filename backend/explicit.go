@@ -0,0 +1,215 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	contentAdvisoryExplicit = "explicit"
+	contentAdvisoryClean    = "clean"
+
+	explicitPreferenceExplicit = "explicit"
+	explicitPreferenceClean    = "clean"
+	explicitPreferenceEither   = "either"
+)
+
+var (
+	cleanVersionPattern       = regexp.MustCompile(`(?i)\b(clean|censored|radio edit|radio version|edited)\b`)
+	advisoryVersionPattern    = regexp.MustCompile(`(?i)\b(explicit|clean|censored|edited)\b`)
+	spotifyTrackExplicitCache sync.Map
+)
+
+func normalizeExplicitPreference(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case explicitPreferenceExplicit:
+		return explicitPreferenceExplicit
+	case explicitPreferenceClean:
+		return explicitPreferenceClean
+	default:
+		return explicitPreferenceEither
+	}
+}
+
+func isCleanVersion(info TitleVersionInfo) bool {
+	for _, version := range info.Versions {
+		if cleanVersionPattern.MatchString(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func SetSpotifyTrackExplicit(spotifyTrackID string, explicit bool) {
+	if trackID, err := extractSpotifyTrackID(spotifyTrackID); err == nil {
+		spotifyTrackExplicitCache.Store(trackID, explicit)
+	}
+}
+
+func FetchSpotifyTrackExplicit(spotifyTrackID string) (bool, error) {
+	trackID, err := extractSpotifyTrackID(spotifyTrackID)
+	if err != nil {
+		return false, err
+	}
+
+	if cached, ok := spotifyTrackExplicitCache.Load(trackID); ok {
+		return cached.(bool), nil
+	}
+
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	if _, err := fetchSpotifyTrackArtists(httpClient, trackID); err != nil {
+		return false, err
+	}
+
+	explicit, ok := spotifyTrackExplicitCache.Load(trackID)
+	if !ok {
+		return false, fmt.Errorf("explicit flag missing for Spotify track %s", trackID)
+	}
+	return explicit.(bool), nil
+}
+
+func ResolveContentAdvisory(spotifyTrackID string, title string, providerExplicit bool) string {
+	if providerExplicit {
+		return contentAdvisoryExplicit
+	}
+
+	if strings.TrimSpace(spotifyTrackID) != "" {
+		explicit, err := FetchSpotifyTrackExplicit(spotifyTrackID)
+		if err != nil {
+			fmt.Printf("[Explicit] Spotify explicit lookup failed: %v\n", err)
+		} else if explicit {
+			return contentAdvisoryExplicit
+		}
+	}
+
+	if isCleanVersion(ParseTitleVersion(title)) {
+		return contentAdvisoryClean
+	}
+
+	return ""
+}
+
+func contentAdvisoryTagValues(advisory string) (string, string) {
+	switch advisory {
+	case contentAdvisoryExplicit:
+		return "1", "1"
+	case contentAdvisoryClean:
+		return "2", "0"
+	default:
+		return "", ""
+	}
+}
+
+func scoreExplicitPreference(explicit bool, candidate TitleVersionInfo, reference TitleVersionInfo) int {
+	clean := !explicit && isCleanVersion(candidate)
+	if isCleanVersion(reference) {
+		if clean {
+			return 80
+		}
+		return 0
+	}
+
+	switch GetExplicitPreferenceSetting() {
+	case explicitPreferenceExplicit:
+		if explicit {
+			return 80
+		}
+		if clean {
+			return -150
+		}
+	case explicitPreferenceClean:
+		if explicit {
+			return -150
+		}
+		if clean {
+			return 80
+		}
+	}
+
+	return 0
+}
+
+func discographyAlbumGroupKey(album DiscographyAlbumMetadata) string {
+	info := ParseTitleVersion(album.Name)
+	parts := []string{info.Title}
+	for _, version := range info.Versions {
+		if !advisoryVersionPattern.MatchString(version) {
+			parts = append(parts, version)
+		}
+	}
+	return strings.Join([]string{
+		strings.ToLower(strings.TrimSpace(album.AlbumType)),
+		normalizeQobuzSearchValue(strings.Join(parts, " ")),
+		fmt.Sprintf("%d", album.TotalTracks),
+	}, "|")
+}
+
+func filterDiscographyByExplicitPreference(albums []DiscographyAlbumMetadata, tracks []AlbumTrackMetadata) ([]DiscographyAlbumMetadata, []AlbumTrackMetadata) {
+	preference := GetExplicitPreferenceSetting()
+	if preference == explicitPreferenceEither {
+		return albums, tracks
+	}
+
+	explicitAlbums := make(map[string]bool)
+	for _, track := range tracks {
+		if track.IsExplicit {
+			explicitAlbums[track.AlbumID] = true
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, album := range albums {
+		key := discographyAlbumGroupKey(album)
+		groups[key] = append(groups[key], album.ID)
+	}
+
+	dropped := make(map[string]struct{})
+	for _, ids := range groups {
+		if len(ids) < 2 {
+			continue
+		}
+
+		hasExplicit, hasClean := false, false
+		for _, id := range ids {
+			if explicitAlbums[id] {
+				hasExplicit = true
+			} else {
+				hasClean = true
+			}
+		}
+		if !hasExplicit || !hasClean {
+			continue
+		}
+
+		for _, id := range ids {
+			if explicitAlbums[id] != (preference == explicitPreferenceExplicit) {
+				dropped[id] = struct{}{}
+			}
+		}
+	}
+
+	if len(dropped) == 0 {
+		return albums, tracks
+	}
+
+	filteredAlbums := make([]DiscographyAlbumMetadata, 0, len(albums)-len(dropped))
+	for _, album := range albums {
+		if _, skip := dropped[album.ID]; !skip {
+			filteredAlbums = append(filteredAlbums, album)
+		}
+	}
+
+	filteredTracks := make([]AlbumTrackMetadata, 0, len(tracks))
+	for _, track := range tracks {
+		if _, skip := dropped[track.AlbumID]; !skip {
+			filteredTracks = append(filteredTracks, track)
+		}
+	}
+
+	fmt.Printf("[Explicit] Skipped %d duplicate release(s) not matching %s preference\n", len(dropped), preference)
+	return filteredAlbums, filteredTracks
+}
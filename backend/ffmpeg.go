@@ -3,16 +3,20 @@ package backend
 import (
 	"archive/tar"
 	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/ulikunitz/xz"
@@ -373,99 +377,84 @@ func InstallFFmpegWithBrew(progressCallback func(int, string)) error {
 	return nil
 }
 
-const ffmpegReleaseBaseURL = "https://github.com/afkarxyz/ffmpeg-binaries/releases/download/v8.1"
+const (
+	ffmpegReleaseBaseURL = "https://github.com/afkarxyz/ffmpeg-binaries/releases/download"
+	ffmpegDefaultVersion = "v8.1"
+)
 
-func buildFFmpegReleaseURL(assetName string) string {
-	return ffmpegReleaseBaseURL + "/" + assetName
+func buildFFmpegReleaseURL(version, assetName string) string {
+	return ffmpegReleaseBaseURL + "/" + version + "/" + assetName
 }
 
-func getFFmpegDownloadURLs() ([]string, []string, error) {
-	switch runtime.GOOS {
+func ffmpegAssetNames(goos, goarch string) (string, string, error) {
+	switch goos {
 	case "windows":
-		return []string{buildFFmpegReleaseURL("ffmpeg-windows.zip")}, []string{buildFFmpegReleaseURL("ffprobe-windows.zip")}, nil
+		return "ffmpeg-windows.zip", "ffprobe-windows.zip", nil
 	case "linux":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "amd64":
-			return []string{buildFFmpegReleaseURL("ffmpeg-linux-amd64.zip")}, []string{buildFFmpegReleaseURL("ffprobe-linux-amd64.zip")}, nil
+			return "ffmpeg-linux-amd64.zip", "ffprobe-linux-amd64.zip", nil
 		case "arm64":
-			return []string{buildFFmpegReleaseURL("ffmpeg-linux-arm64v8.zip")}, []string{buildFFmpegReleaseURL("ffprobe-linux-arm64v8.zip")}, nil
+			return "ffmpeg-linux-arm64v8.zip", "ffprobe-linux-arm64v8.zip", nil
 		default:
-			return nil, nil, fmt.Errorf("unsupported Linux architecture: %s", runtime.GOARCH)
+			return "", "", fmt.Errorf("unsupported Linux architecture: %s", goarch)
 		}
 	case "darwin":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "amd64":
-			return []string{buildFFmpegReleaseURL("ffmpeg-macos-amd64.zip")}, []string{buildFFmpegReleaseURL("ffprobe-macos-amd64.zip")}, nil
+			return "ffmpeg-macos-amd64.zip", "ffprobe-macos-amd64.zip", nil
 		case "arm64":
-			return []string{buildFFmpegReleaseURL("ffmpeg-macos-arm64.zip")}, []string{buildFFmpegReleaseURL("ffprobe-macos-arm64.zip")}, nil
+			return "ffmpeg-macos-arm64.zip", "ffprobe-macos-arm64.zip", nil
 		default:
-			return nil, nil, fmt.Errorf("unsupported macOS architecture: %s", runtime.GOARCH)
+			return "", "", fmt.Errorf("unsupported macOS architecture: %s", goarch)
 		}
 	default:
-		return nil, nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+		return "", "", fmt.Errorf("unsupported operating system: %s", goos)
 	}
 }
 
-func DownloadFFmpeg(progressCallback func(int)) error {
-
-	SetDownloadProgress(0)
-	SetDownloadSpeed(0)
-	SetDownloading(true)
-	defer SetDownloading(false)
-
-	ffmpegDir, err := GetFFmpegDir()
+func getFFmpegDownloadURLs(version string) ([]string, []string, error) {
+	ffmpegAsset, ffprobeAsset, err := ffmpegAssetNames(runtime.GOOS, runtime.GOARCH)
 	if err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(ffmpegDir, 0755); err != nil {
-		return fmt.Errorf("failed to create ffmpeg directory: %w", err)
+		return nil, nil, err
 	}
+	return []string{buildFFmpegReleaseURL(version, ffmpegAsset)}, []string{buildFFmpegReleaseURL(version, ffprobeAsset)}, nil
+}
 
+func DownloadFFmpeg(progressCallback func(int)) error {
 	ffmpegInstalled, _ := IsFFmpegInstalled()
 	ffprobeInstalled, _ := IsFFprobeInstalled()
-
-	ffmpegURLs, ffprobeURLs, err := getFFmpegDownloadURLs()
-	if err != nil {
-		return err
-	}
-
-	if !ffmpegInstalled && !ffprobeInstalled {
-		if err := downloadWithFallback(ffmpegURLs, ffmpegDir, progressCallback, 0, 50); err != nil {
-			return err
-		}
-		if err := downloadWithFallback(ffprobeURLs, ffmpegDir, progressCallback, 50, 100); err != nil {
-			return err
-		}
+	if ffmpegInstalled && ffprobeInstalled {
 		return nil
 	}
 
-	if !ffmpegInstalled {
-		return downloadWithFallback(ffmpegURLs, ffmpegDir, progressCallback, 0, 100)
-	}
-
-	if !ffprobeInstalled {
-		return downloadWithFallback(ffprobeURLs, ffmpegDir, progressCallback, 0, 100)
-	}
-
-	return nil
+	_, err := installFFmpegVersion(pinnedFFmpegVersion(), !ffmpegInstalled, !ffprobeInstalled, progressCallback)
+	return err
 }
 
-func downloadWithFallback(urls []string, destDir string, progressCallback func(int), start, end int) error {
+func downloadWithFallback(urls []string, manifest map[string]string, destDir string, progressCallback func(int), start, end int) (string, error) {
 	var lastErr error
 	for _, url := range urls {
+		asset := path.Base(url)
+		expected, ok := manifest[asset]
+		if !ok {
+			lastErr = fmt.Errorf("no pinned checksum for %s", asset)
+			fmt.Printf("[FFmpeg] Skipping %s: %v\n", url, lastErr)
+			continue
+		}
+
 		fmt.Printf("[FFmpeg] Trying to download from: %s\n", url)
-		err := downloadAndExtract(url, destDir, progressCallback, start, end)
+		err := downloadAndExtract(url, expected, destDir, progressCallback, start, end)
 		if err == nil {
-			return nil
+			return asset, nil
 		}
 		lastErr = err
 		fmt.Printf("[FFmpeg] Attempt failed: %v\n", err)
 	}
-	return fmt.Errorf("all download attempts failed: %w", lastErr)
+	return "", fmt.Errorf("all download attempts failed: %w", lastErr)
 }
 
-func downloadAndExtract(url, destDir string, progressCallback func(int), progressStart, progressEnd int) error {
+func downloadAndExtract(url, expectedSHA256, destDir string, progressCallback func(int), progressStart, progressEnd int) error {
 
 	tmpFile, err := os.CreateTemp("", "ffmpeg-*")
 	if err != nil {
@@ -492,6 +481,7 @@ func downloadAndExtract(url, destDir string, progressCallback func(int), progres
 	}
 
 	totalSize := resp.ContentLength
+	hasher := sha256.New()
 	var downloaded int64
 	lastTime := time.Now()
 	var lastBytes int64
@@ -511,6 +501,7 @@ func downloadAndExtract(url, destDir string, progressCallback func(int), progres
 			if writeErr != nil {
 				return fmt.Errorf("failed to write to temp file: %w", writeErr)
 			}
+			hasher.Write(buf[:n])
 			downloaded += int64(n)
 
 			mbDownloaded := float64(downloaded) / (1024 * 1024)
@@ -569,6 +560,11 @@ func downloadAndExtract(url, destDir string, progressCallback func(int), progres
 	} else {
 		fmt.Printf("\r[FFmpeg] Download complete: %.2f MB          \n", float64(downloaded)/(1024*1024))
 	}
+
+	if sum := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(sum, expectedSHA256) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path.Base(url), expectedSHA256, sum)
+	}
+	fmt.Printf("[FFmpeg] Checksum verified\n")
 	fmt.Printf("[FFmpeg] Extracting...\n")
 
 	if strings.HasSuffix(url, ".tar.xz") {
@@ -743,6 +739,8 @@ type ConvertAudioRequest struct {
 	OutputFormat string   `json:"output_format"`
 	Bitrate      string   `json:"bitrate"`
 	Codec        string   `json:"codec"`
+	Preset       string   `json:"preset,omitempty"`
+	JobID        string   `json:"job_id,omitempty"`
 }
 
 type ConvertAudioResult struct {
@@ -752,7 +750,7 @@ type ConvertAudioResult struct {
 	Error      string `json:"error,omitempty"`
 }
 
-func ConvertAudio(req ConvertAudioRequest) ([]ConvertAudioResult, error) {
+func ConvertAudio(req ConvertAudioRequest, progress func(AudioJobProgress)) ([]ConvertAudioResult, error) {
 	ffmpegPath, err := GetFFmpegPath()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ffmpeg path: %w", err)
@@ -767,161 +765,152 @@ func ConvertAudio(req ConvertAudioRequest) ([]ConvertAudioResult, error) {
 		return nil, fmt.Errorf("ffmpeg is not installed")
 	}
 
-	results := make([]ConvertAudioResult, len(req.InputFiles))
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	preset, _ := normalizeConversionPreset(ConversionPreset{
+		Name:    "custom",
+		Format:  req.OutputFormat,
+		Codec:   req.Codec,
+		Bitrate: req.Bitrate,
+	})
+	if strings.TrimSpace(req.Preset) != "" {
+		found, ok := FindConversionPreset(req.Preset)
+		if !ok {
+			return nil, fmt.Errorf("conversion preset not found: %s", req.Preset)
+		}
+		preset = found
+		fmt.Printf("[FFmpeg] Using conversion preset: %s\n", preset.Name)
+	}
+	if !conversionFormats[preset.Format] {
+		return nil, fmt.Errorf("unsupported output format: %s", preset.Format)
+	}
 
-	for i, inputFile := range req.InputFiles {
-		wg.Add(1)
-		go func(idx int, inputFile string) {
-			defer wg.Done()
+	results := make([]ConvertAudioResult, len(req.InputFiles))
+	job := startAudioJob(req.JobID, len(req.InputFiles), progress)
+	defer job.finish()
 
-			result := ConvertAudioResult{
-				InputFile: inputFile,
-			}
+	job.run(func(idx int) {
+		inputFile := req.InputFiles[idx]
+		result := ConvertAudioResult{
+			InputFile: inputFile,
+		}
+		defer func() {
+			results[idx] = result
+		}()
 
-			inputExt := strings.ToLower(filepath.Ext(inputFile))
-			baseName := strings.TrimSuffix(filepath.Base(inputFile), inputExt)
-			inputDir := filepath.Dir(inputFile)
+		if job.cancelled() {
+			result.Error = "cancelled"
+			job.report(idx, inputFile, audioJobStatusCancelled, 0)
+			return
+		}
+		job.report(idx, inputFile, audioJobStatusRunning, 0)
 
-			outputFormatUpper := strings.ToUpper(req.OutputFormat)
-			outputDir := filepath.Join(inputDir, outputFormatUpper)
+		inputExt := strings.ToLower(filepath.Ext(inputFile))
+		baseName := strings.TrimSuffix(filepath.Base(inputFile), inputExt)
+		inputDir := filepath.Dir(inputFile)
 
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				result.Error = fmt.Sprintf("failed to create output directory: %v", err)
-				result.Success = false
-				mu.Lock()
-				results[idx] = result
-				mu.Unlock()
-				return
-			}
+		outputFormatUpper := strings.ToUpper(preset.Format)
+		outputDir := filepath.Join(inputDir, outputFormatUpper)
 
-			outputExt := "." + strings.ToLower(req.OutputFormat)
-			outputFile := filepath.Join(outputDir, baseName+outputExt)
-			outputFile = norm.NFC.String(outputFile)
-
-			if inputExt == outputExt {
-				result.Error = "Input and output formats are the same"
-				result.Success = false
-				mu.Lock()
-				results[idx] = result
-				mu.Unlock()
-				return
-			}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			result.Error = fmt.Sprintf("failed to create output directory: %v", err)
+			result.Success = false
+			job.report(idx, inputFile, audioJobStatusFailed, 0)
+			return
+		}
 
-			result.OutputFile = outputFile
+		outputExt := "." + preset.Format
+		outputFile := filepath.Join(outputDir, baseName+outputExt)
+		outputFile = norm.NFC.String(outputFile)
 
-			var coverArtPath string
-			var lyrics string
-			var inputMetadata Metadata
+		if inputExt == outputExt && outputExt != ".flac" {
+			result.Error = "Input and output formats are the same"
+			result.Success = false
+			job.report(idx, inputFile, audioJobStatusFailed, 0)
+			return
+		}
 
-			inputMetadata, err = ExtractFullMetadataFromFile(inputFile)
-			if err != nil {
-				fmt.Printf("[FFmpeg] Warning: Failed to extract metadata from %s: %v\n", inputFile, err)
-			}
+		result.OutputFile = outputFile
 
-			inputFile = norm.NFC.String(inputFile)
-			coverArtPath, err = ExtractCoverArt(inputFile)
-			if err != nil {
-				fmt.Printf("[FFmpeg] Warning: Failed to extract cover art from %s: %v\n", inputFile, err)
-			}
-			lyrics, err = ExtractLyrics(inputFile)
-			if err != nil {
-				fmt.Printf("[FFmpeg] Warning: Failed to extract lyrics from %s: %v\n", inputFile, err)
-			} else if lyrics != "" {
-				fmt.Printf("[FFmpeg] Lyrics extracted from %s: %d characters\n", inputFile, len(lyrics))
+		err := convertAudioFile(job.ctx, ffmpegPath, norm.NFC.String(inputFile), outputFile, preset, func(percent float64) {
+			job.report(idx, inputFile, audioJobStatusRunning, percent)
+		})
+		if err != nil {
+			if job.statusForError(err) == audioJobStatusCancelled {
+				result.Error = "cancelled"
 			} else {
-				fmt.Printf("[FFmpeg] No lyrics found in %s\n", inputFile)
-			}
-
-			inputMetadata.Lyrics = lyrics
-
-			args := []string{
-				"-i", inputFile,
-				"-y",
+				result.Error = err.Error()
 			}
+			result.Success = false
+			job.report(idx, inputFile, job.statusForError(err), 0)
+			return
+		}
 
-			switch req.OutputFormat {
-			case "mp3":
-				args = append(args,
-					"-codec:a", "libmp3lame",
-					"-b:a", req.Bitrate,
-					"-map", "0:a",
-					"-id3v2_version", "3",
-				)
-			case "m4a":
-
-				codec := req.Codec
-				if codec == "" {
-					codec = "aac"
-				}
-
-				if codec == "alac" {
-
-					args = append(args,
-						"-codec:a", "alac",
-						"-map", "0:a",
-					)
-				} else {
-
-					args = append(args,
-						"-codec:a", "aac",
-						"-b:a", req.Bitrate,
-						"-map", "0:a",
-					)
-				}
-			}
+		result.Success = true
+		fmt.Printf("[FFmpeg] Successfully converted: %s\n", outputFile)
+		job.report(idx, inputFile, audioJobStatusDone, 100)
+	})
 
-			args = append(args, outputFile)
+	return results, nil
+}
 
-			fmt.Printf("[FFmpeg] Converting: %s -> %s\n", inputFile, outputFile)
+func convertAudioFile(ctx context.Context, ffmpegPath, inputFile, outputFile string, preset ConversionPreset, onPercent func(float64)) error {
+	inputMetadata, err := ExtractFullMetadataFromFile(inputFile)
+	if err != nil {
+		fmt.Printf("[FFmpeg] Warning: Failed to extract metadata from %s: %v\n", inputFile, err)
+	}
 
-			cmd := exec.Command(ffmpegPath, args...)
+	coverArtPath, err := ExtractCoverArt(inputFile)
+	if err != nil {
+		fmt.Printf("[FFmpeg] Warning: Failed to extract cover art from %s: %v\n", inputFile, err)
+	}
+	if coverArtPath != "" {
+		defer os.Remove(coverArtPath)
+	}
 
-			setHideWindow(cmd)
-			output, err := cmd.CombinedOutput()
-			if err != nil {
-				result.Error = fmt.Sprintf("conversion failed: %s - %s", err.Error(), string(output))
-				result.Success = false
-				mu.Lock()
-				results[idx] = result
-				mu.Unlock()
+	lyrics, err := ExtractLyrics(inputFile)
+	if err != nil {
+		fmt.Printf("[FFmpeg] Warning: Failed to extract lyrics from %s: %v\n", inputFile, err)
+	} else if lyrics != "" {
+		fmt.Printf("[FFmpeg] Lyrics extracted from %s: %d characters\n", inputFile, len(lyrics))
+	} else {
+		fmt.Printf("[FFmpeg] No lyrics found in %s\n", inputFile)
+	}
 
-				if coverArtPath != "" {
-					os.Remove(coverArtPath)
-				}
-				return
-			}
+	inputMetadata.Lyrics = lyrics
 
-			if err := EmbedMetadataToConvertedFile(outputFile, inputMetadata, coverArtPath); err != nil {
-				fmt.Printf("[FFmpeg] Warning: Failed to embed metadata: %v\n", err)
-			} else {
-				fmt.Printf("[FFmpeg] Metadata embedded successfully\n")
-			}
+	args := []string{
+		"-i", inputFile,
+		"-y",
+	}
+	args = append(args, preset.ffmpegArgs(probeAudioInfo(ctx, inputFile))...)
+	args = append(args, outputFile)
 
-			if lyrics != "" {
-				if err := EmbedLyricsOnlyUniversal(outputFile, lyrics); err != nil {
-					fmt.Printf("[FFmpeg] Warning: Failed to embed lyrics: %v\n", err)
-				} else {
-					fmt.Printf("[FFmpeg] Lyrics embedded successfully\n")
-				}
-			}
+	fmt.Printf("[FFmpeg] Converting: %s -> %s\n", inputFile, outputFile)
 
-			if coverArtPath != "" {
-				os.Remove(coverArtPath)
-			}
+	duration, _ := GetAudioDuration(inputFile)
+	output, err := runFFmpegWithProgress(ctx, ffmpegPath, args, duration, onPercent)
+	if err != nil {
+		if errors.Is(err, errAudioJobCancelled) {
+			os.Remove(outputFile)
+			return err
+		}
+		return fmt.Errorf("conversion failed: %s - %s", err.Error(), string(output))
+	}
 
-			result.Success = true
-			fmt.Printf("[FFmpeg] Successfully converted: %s\n", outputFile)
+	if err := EmbedMetadataToConvertedFile(outputFile, inputMetadata, coverArtPath); err != nil {
+		fmt.Printf("[FFmpeg] Warning: Failed to embed metadata: %v\n", err)
+	} else {
+		fmt.Printf("[FFmpeg] Metadata embedded successfully\n")
+	}
 
-			mu.Lock()
-			results[idx] = result
-			mu.Unlock()
-		}(i, inputFile)
+	if lyrics != "" {
+		if err := EmbedLyricsOnlyUniversal(outputFile, lyrics); err != nil {
+			fmt.Printf("[FFmpeg] Warning: Failed to embed lyrics: %v\n", err)
+		} else {
+			fmt.Printf("[FFmpeg] Lyrics embedded successfully\n")
+		}
 	}
 
-	wg.Wait()
-	return results, nil
+	return nil
 }
 
 type AudioFileInfo struct {
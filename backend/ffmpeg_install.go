@@ -0,0 +1,333 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ffmpegInstallStateFile = "ffmpeg-install.json"
+	ffmpegPreviousSuffix   = ".previous"
+)
+
+//go:embed ffmpeg_checksums.txt
+var bundledFFmpegChecksums []byte
+
+type FFmpegInstallState struct {
+	Version     string            `json:"version"`
+	Checksums   map[string]string `json:"checksums"`
+	InstalledAt int64             `json:"installed_at"`
+}
+
+func normalizeFFmpegVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return ""
+	}
+	if version[0] >= '0' && version[0] <= '9' {
+		version = "v" + version
+	}
+	for _, r := range version {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return ""
+		}
+	}
+	return version
+}
+
+func getFFmpegInstallStatePath() (string, error) {
+	ffmpegDir, err := GetFFmpegDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(ffmpegDir, ffmpegInstallStateFile), nil
+}
+
+func LoadFFmpegInstallState() (*FFmpegInstallState, error) {
+	statePath, err := getFFmpegInstallStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state FFmpegInstallState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse ffmpeg install state: %w", err)
+	}
+	return &state, nil
+}
+
+func saveFFmpegInstallState(state *FFmpegInstallState) error {
+	statePath, err := getFFmpegInstallStatePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0644)
+}
+
+func pinnedFFmpegVersion() string {
+	if version := GetFFmpegVersionSetting(); version != "" {
+		return version
+	}
+	if state, err := LoadFFmpegInstallState(); err == nil && state != nil {
+		if version := normalizeFFmpegVersion(state.Version); version != "" {
+			return version
+		}
+	}
+	return ffmpegDefaultVersion
+}
+
+func parseChecksumManifest(data []byte) (map[string]string, error) {
+	manifest := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		sum := strings.ToLower(fields[0])
+		if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("invalid SHA-256 checksum for %s", fields[1])
+		}
+		manifest[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func pinnedFFmpegChecksums(version string) (map[string]string, error) {
+	all, err := parseChecksumManifest(bundledFFmpegChecksums)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundled ffmpeg checksums: %w", err)
+	}
+
+	checksums := make(map[string]string)
+	for name, sum := range all {
+		if assetVersion, asset, ok := strings.Cut(name, "/"); ok && assetVersion == version && asset != "" {
+			checksums[asset] = sum
+		}
+	}
+	if len(checksums) == 0 {
+		supported := GetSupportedFFmpegVersions()
+		if len(supported) == 0 {
+			return nil, fmt.Errorf("ffmpeg %s has no pinned checksums and no versions are available", version)
+		}
+		return nil, fmt.Errorf("ffmpeg %s has no pinned checksums; supported versions: %s", version, strings.Join(supported, ", "))
+	}
+	return checksums, nil
+}
+
+func GetSupportedFFmpegVersions() []string {
+	all, err := parseChecksumManifest(bundledFFmpegChecksums)
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var versions []string
+	for name := range all {
+		if version, _, ok := strings.Cut(name, "/"); ok && !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func verifyInstalledExecutable(path string) error {
+	if err := prepareExecutableForUse(path); err != nil {
+		return err
+	}
+	if err := ValidateExecutable(path); err != nil {
+		return err
+	}
+	return runExecutableVersionCheck(path)
+}
+
+func installStagedExecutables(stagingDir, destDir string) error {
+	entries, err := os.ReadDir(stagingDir)
+	if err != nil {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+
+	type replacedExecutable struct {
+		dest        string
+		backup      string
+		hadPrevious bool
+	}
+	var replaced []replacedExecutable
+
+	rollback := func() {
+		for i := len(replaced) - 1; i >= 0; i-- {
+			item := replaced[i]
+			os.Remove(item.dest)
+			if item.hadPrevious {
+				if err := os.Rename(item.backup, item.dest); err != nil {
+					fmt.Printf("[FFmpeg] Failed to restore %s: %v\n", item.dest, err)
+					continue
+				}
+				fmt.Printf("[FFmpeg] Restored previous %s\n", filepath.Base(item.dest))
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		staged := filepath.Join(stagingDir, entry.Name())
+		item := replacedExecutable{
+			dest:   filepath.Join(destDir, entry.Name()),
+			backup: filepath.Join(destDir, entry.Name()+ffmpegPreviousSuffix),
+		}
+
+		if _, err := os.Stat(item.dest); err == nil {
+			os.Remove(item.backup)
+			if err := os.Rename(item.dest, item.backup); err != nil {
+				rollback()
+				return fmt.Errorf("failed to back up %s: %w", entry.Name(), err)
+			}
+			item.hadPrevious = true
+		}
+		replaced = append(replaced, item)
+
+		if err := os.Rename(staged, item.dest); err != nil {
+			rollback()
+			return fmt.Errorf("failed to install %s: %w", entry.Name(), err)
+		}
+
+		if err := verifyInstalledExecutable(item.dest); err != nil {
+			fmt.Printf("[FFmpeg] %s failed validation, rolling back: %v\n", entry.Name(), err)
+			rollback()
+			return fmt.Errorf("installed %s failed validation: %w", entry.Name(), err)
+		}
+	}
+
+	for _, item := range replaced {
+		if item.hadPrevious {
+			os.Remove(item.backup)
+		}
+	}
+	return nil
+}
+
+func installFFmpegVersion(version string, withFFmpeg, withFFprobe bool, progressCallback func(int)) (*FFmpegInstallState, error) {
+	SetDownloadProgress(0)
+	SetDownloadSpeed(0)
+	SetDownloading(true)
+	defer SetDownloading(false)
+
+	ffmpegDir, err := GetFFmpegDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ffmpegURLs, ffprobeURLs, err := getFFmpegDownloadURLs(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var downloads [][]string
+	if withFFmpeg {
+		downloads = append(downloads, ffmpegURLs)
+	}
+	if withFFprobe {
+		downloads = append(downloads, ffprobeURLs)
+	}
+	if len(downloads) == 0 {
+		return LoadFFmpegInstallState()
+	}
+
+	manifest, err := pinnedFFmpegChecksums(version)
+	if err != nil {
+		return nil, err
+	}
+
+	stagingDir, err := os.MkdirTemp(ffmpegDir, ".ffmpeg-staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	state := &FFmpegInstallState{Version: version, Checksums: make(map[string]string)}
+	if previous, err := LoadFFmpegInstallState(); err == nil && previous != nil && previous.Version == version {
+		for asset, sum := range previous.Checksums {
+			state.Checksums[asset] = sum
+		}
+	}
+
+	step := 100 / len(downloads)
+	for i, urls := range downloads {
+		asset, err := downloadWithFallback(urls, manifest, stagingDir, progressCallback, i*step, (i+1)*step)
+		if err != nil {
+			return nil, err
+		}
+		state.Checksums[asset] = manifest[asset]
+	}
+
+	if err := installStagedExecutables(stagingDir, ffmpegDir); err != nil {
+		return nil, err
+	}
+
+	state.InstalledAt = time.Now().Unix()
+	if err := saveFFmpegInstallState(state); err != nil {
+		fmt.Printf("[FFmpeg] Warning: failed to save install state: %v\n", err)
+	}
+	fmt.Printf("[FFmpeg] Installed version %s\n", version)
+	return state, nil
+}
+
+func UpgradeFFmpeg(version string, progressCallback func(int)) (*FFmpegInstallState, error) {
+	target := normalizeFFmpegVersion(version)
+	if strings.TrimSpace(version) != "" && target == "" {
+		return nil, fmt.Errorf("invalid ffmpeg version: %s", version)
+	}
+
+	pinned := GetFFmpegVersionSetting()
+	if target == "" {
+		target = pinned
+	}
+	if target == "" {
+		target = ffmpegDefaultVersion
+	}
+	if pinned != "" && pinned != target {
+		return nil, fmt.Errorf("ffmpeg is pinned to %s by the ffmpegVersion setting", pinned)
+	}
+
+	if _, err := pinnedFFmpegChecksums(target); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[FFmpeg] Upgrading to %s\n", target)
+	return installFFmpegVersion(target, true, true, progressCallback)
+}
@@ -0,0 +1,29 @@
+package backend
+
+import "testing"
+
+func TestPinnedFFmpegChecksumsCoverSupportedPlatforms(t *testing.T) {
+	platforms := [][2]string{
+		{"windows", "amd64"},
+		{"linux", "amd64"},
+		{"linux", "arm64"},
+		{"darwin", "amd64"},
+		{"darwin", "arm64"},
+	}
+
+	checksums, err := pinnedFFmpegChecksums(ffmpegDefaultVersion)
+	if err != nil {
+		t.Fatalf("pinnedFFmpegChecksums(%s): %v", ffmpegDefaultVersion, err)
+	}
+	for _, platform := range platforms {
+		ffmpegAsset, ffprobeAsset, err := ffmpegAssetNames(platform[0], platform[1])
+		if err != nil {
+			t.Fatalf("ffmpegAssetNames(%s, %s): %v", platform[0], platform[1], err)
+		}
+		for _, asset := range []string{ffmpegAsset, ffprobeAsset} {
+			if checksums[asset] == "" {
+				t.Errorf("%s/%s: no pinned checksum for %s/%s", platform[0], platform[1], ffmpegDefaultVersion, asset)
+			}
+		}
+	}
+}
@@ -11,9 +11,9 @@ import (
 )
 
 func buildFormattedFilenameBase(trackName, artistName, albumName, albumArtist, releaseDate, filenameFormat, playlistName, playlistOwner, isrc string, includeTrackNumber bool, position, discNumber int, useAlbumTrackNumber bool) string {
-	safeTitle := SanitizeFilename(trackName)
+	safeTitle := SanitizeFilename(ResolveFilenameTitle(trackName))
 	safeArtist := SanitizeFilename(artistName)
-	safeAlbum := SanitizeFilename(albumName)
+	safeAlbum := SanitizeFilename(ResolveFilenameTitle(albumName))
 	safeAlbumArtist := SanitizeFilename(albumArtist)
 	safeISRC := SanitizeOptionalFilename(isrc)
 
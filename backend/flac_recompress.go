@@ -0,0 +1,294 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-flac/go-flac"
+)
+
+const (
+	flacRecompressStatusReplaced     = "replaced"
+	flacRecompressStatusWouldReplace = "would_replace"
+	flacRecompressStatusKept         = "kept"
+	flacRecompressStatusMismatch     = "mismatch"
+	flacRecompressStatusFailed       = "failed"
+	flacRecompressStatusCancelled    = "cancelled"
+
+	maxFLACCompressionLevel = 12
+)
+
+type FlacRecompressRequest struct {
+	InputFiles       []string `json:"input_files,omitempty"`
+	FolderPath       string   `json:"folder_path,omitempty"`
+	CompressionLevel *int     `json:"compression_level,omitempty"`
+	DryRun           bool     `json:"dry_run"`
+	JobID            string   `json:"job_id,omitempty"`
+}
+
+type FlacRecompressResult struct {
+	File         string `json:"file"`
+	Status       string `json:"status"`
+	OriginalSize int64  `json:"original_size"`
+	NewSize      int64  `json:"new_size,omitempty"`
+	BytesSaved   int64  `json:"bytes_saved"`
+	BytesAdded   int64  `json:"bytes_added,omitempty"`
+	MD5Added     bool   `json:"md5_added,omitempty"`
+	AudioMD5     string `json:"audio_md5,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type FlacRecompressSummary struct {
+	Total      int                    `json:"total"`
+	Replaced   int                    `json:"replaced"`
+	Kept       int                    `json:"kept"`
+	Failed     int                    `json:"failed"`
+	BytesSaved int64                  `json:"bytes_saved"`
+	BytesAdded int64                  `json:"bytes_added,omitempty"`
+	DryRun     bool                   `json:"dry_run"`
+	Results    []FlacRecompressResult `json:"results"`
+}
+
+func flacStreamInfoMD5(path string) string {
+	info, err := readNativeAudioInfo(path)
+	if err != nil {
+		return ""
+	}
+	return info.MD5
+}
+
+func decodedAudioMD5(ctx context.Context, ffmpegPath, path string, bitsPerSample int) (string, error) {
+	codec := "pcm_s16le"
+	switch {
+	case bitsPerSample > 0 && bitsPerSample <= 8:
+		codec = "pcm_s8"
+	case bitsPerSample > 24:
+		codec = "pcm_s32le"
+	case bitsPerSample > 16:
+		codec = "pcm_s24le"
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-v", "error", "-i", path, "-map", "0:a", "-c:a", codec, "-f", "md5", "-")
+	setHideWindow(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", errAudioJobCancelled
+		}
+		return "", fmt.Errorf("failed to decode %s: %s - %s", filepath.Base(path), err.Error(), stderr.String())
+	}
+
+	_, sum, ok := strings.Cut(strings.TrimSpace(string(out)), "=")
+	if !ok || len(sum) != 32 {
+		return "", fmt.Errorf("unexpected md5 output for %s", filepath.Base(path))
+	}
+	return strings.ToLower(sum), nil
+}
+
+func recompressFlacFile(ctx context.Context, ffmpegPath, inputFile string, level int, dryRun bool, onPercent func(float64)) FlacRecompressResult {
+	result := FlacRecompressResult{File: inputFile}
+
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		result.Status = flacRecompressStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.OriginalSize = info.Size()
+
+	fail := func(err error) FlacRecompressResult {
+		if errors.Is(err, errAudioJobCancelled) || ctx.Err() != nil {
+			result.Status = flacRecompressStatusCancelled
+			result.Error = "cancelled"
+		} else {
+			result.Status = flacRecompressStatusFailed
+			result.Error = err.Error()
+		}
+		return result
+	}
+
+	source := probeAudioInfo(ctx, inputFile)
+	bits := int(source.BitsPerSample)
+	storedMD5 := flacStreamInfoMD5(inputFile)
+
+	sourceMD5, err := decodedAudioMD5(ctx, ffmpegPath, inputFile, bits)
+	if err != nil {
+		return fail(err)
+	}
+	if storedMD5 != "" && storedMD5 != sourceMD5 {
+		result.Status = flacRecompressStatusMismatch
+		result.Error = "decoded audio does not match the STREAMINFO MD5, source may be corrupt"
+		return result
+	}
+
+	tmpPath := strings.TrimSuffix(inputFile, filepath.Ext(inputFile)) + ".recompress.flac"
+	defer os.Remove(tmpPath)
+
+	args := []string{
+		"-i", inputFile,
+		"-y",
+		"-map", "0:a",
+		"-c:a", "flac",
+		"-compression_level", strconv.Itoa(level),
+	}
+	if bits > 16 {
+		args = append(args, "-sample_fmt", "s32", "-bits_per_raw_sample", strconv.Itoa(bits))
+	}
+	args = append(args, tmpPath)
+
+	duration, _ := GetAudioDuration(inputFile)
+	output, err := runFFmpegWithProgress(ctx, ffmpegPath, args, duration, func(percent float64) {
+		if onPercent != nil {
+			onPercent(percent * 0.8)
+		}
+	})
+	if err != nil {
+		if errors.Is(err, errAudioJobCancelled) {
+			return fail(err)
+		}
+		return fail(fmt.Errorf("re-encode failed: %s - %s", err.Error(), string(output)))
+	}
+
+	newMD5, err := decodedAudioMD5(ctx, ffmpegPath, tmpPath, bits)
+	if err != nil {
+		return fail(err)
+	}
+	if newMD5 != sourceMD5 {
+		result.Status = flacRecompressStatusMismatch
+		result.Error = "re-encoded audio does not match the original"
+		return result
+	}
+	if onPercent != nil {
+		onPercent(90)
+	}
+
+	if err := copyFlacMetadataBlocks(inputFile, tmpPath, flac.Padding, flac.Application, flac.VorbisComment, flac.CueSheet, flac.Picture); err != nil {
+		return fail(err)
+	}
+
+	newInfo, err := os.Stat(tmpPath)
+	if err != nil {
+		return fail(err)
+	}
+	result.NewSize = newInfo.Size()
+	result.AudioMD5 = sourceMD5
+	result.MD5Added = storedMD5 == "" && flacStreamInfoMD5(tmpPath) == sourceMD5
+
+	if result.NewSize >= result.OriginalSize && !result.MD5Added {
+		result.Status = flacRecompressStatusKept
+		return result
+	}
+	result.BytesSaved = max(result.OriginalSize-result.NewSize, 0)
+	result.BytesAdded = max(result.NewSize-result.OriginalSize, 0)
+
+	if dryRun {
+		result.Status = flacRecompressStatusWouldReplace
+		return result
+	}
+	if err := os.Rename(tmpPath, inputFile); err != nil {
+		return fail(fmt.Errorf("failed to replace original file: %w", err))
+	}
+	result.Status = flacRecompressStatusReplaced
+	return result
+}
+
+func RecompressFlac(req FlacRecompressRequest, progress func(AudioJobProgress)) (*FlacRecompressSummary, error) {
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ffmpeg path: %w", err)
+	}
+	if err := ValidateExecutable(ffmpegPath); err != nil {
+		return nil, fmt.Errorf("invalid ffmpeg executable: %w", err)
+	}
+
+	level := defaultFLACCompressionLevel
+	if req.CompressionLevel != nil {
+		level = min(max(*req.CompressionLevel, 0), maxFLACCompressionLevel)
+	}
+
+	var files []string
+	seen := map[string]bool{}
+	addFile := func(path string) {
+		if strings.EqualFold(filepath.Ext(path), ".flac") && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, path := range req.InputFiles {
+		addFile(path)
+	}
+	if folder := strings.TrimSpace(req.FolderPath); folder != "" {
+		entries, err := ListAudioFiles(NormalizePath(folder))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			addFile(entry.Path)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no FLAC files to recompress")
+	}
+
+	fmt.Printf("[Recompress] %d FLAC files at compression level %d (dry run: %t)\n", len(files), level, req.DryRun)
+
+	results := make([]FlacRecompressResult, len(files))
+	job := startAudioJob(req.JobID, len(files), progress)
+	defer job.finish()
+
+	job.run(func(idx int) {
+		inputFile := files[idx]
+		if job.cancelled() {
+			results[idx] = FlacRecompressResult{File: inputFile, Status: flacRecompressStatusCancelled, Error: "cancelled"}
+			job.report(idx, inputFile, audioJobStatusCancelled, 0)
+			return
+		}
+		job.report(idx, inputFile, audioJobStatusRunning, 0)
+
+		result := recompressFlacFile(job.ctx, ffmpegPath, inputFile, level, req.DryRun, func(percent float64) {
+			job.report(idx, inputFile, audioJobStatusRunning, percent)
+		})
+		results[idx] = result
+
+		switch result.Status {
+		case flacRecompressStatusCancelled:
+			job.report(idx, inputFile, audioJobStatusCancelled, 0)
+		case flacRecompressStatusFailed, flacRecompressStatusMismatch:
+			fmt.Printf("[Recompress] %s: %s\n", inputFile, result.Error)
+			job.report(idx, inputFile, audioJobStatusFailed, 0)
+		default:
+			job.report(idx, inputFile, audioJobStatusDone, 100)
+		}
+	})
+
+	summary := &FlacRecompressSummary{
+		Total:   len(files),
+		DryRun:  req.DryRun,
+		Results: results,
+	}
+	for _, result := range results {
+		switch result.Status {
+		case flacRecompressStatusReplaced, flacRecompressStatusWouldReplace:
+			summary.Replaced++
+			summary.BytesSaved += result.BytesSaved
+			summary.BytesAdded += result.BytesAdded
+		case flacRecompressStatusKept:
+			summary.Kept++
+		case flacRecompressStatusFailed, flacRecompressStatusMismatch:
+			summary.Failed++
+		}
+	}
+
+	fmt.Printf("[Recompress] Done: %d replaced, %d kept, %d failed, %d bytes saved, %d bytes added\n",
+		summary.Replaced, summary.Kept, summary.Failed, summary.BytesSaved, summary.BytesAdded)
+	return summary, nil
+}
@@ -0,0 +1,220 @@
+package backend
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+const (
+	userGenreTreeFile = "genres.json"
+	defaultGenreCount = 5
+)
+
+//go:embed genre_tree.json
+var bundledGenreTree []byte
+
+type genreTreeFile struct {
+	Tree    map[string]json.RawMessage `json:"tree"`
+	Aliases map[string]string          `json:"aliases"`
+}
+
+type genreNode struct {
+	Name   string
+	Parent string
+}
+
+type GenreTree struct {
+	nodes   map[string]genreNode
+	aliases map[string]string
+}
+
+var (
+	genreTreeMu        sync.Mutex
+	genreTreeCache     *GenreTree
+	genreTreeUserMtime time.Time
+)
+
+func normalizeGenreKey(value string) string {
+	replacer := strings.NewReplacer(
+		"&", " and ",
+		"-", " ",
+		"_", " ",
+		"/", " ",
+		".", " ",
+		"'", " ",
+	)
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	normalized = replacer.Replace(normalized)
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+func parseGenreTree(data []byte) (*GenreTree, error) {
+	var file genreTreeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse genre tree: %w", err)
+	}
+
+	tree := &GenreTree{
+		nodes:   make(map[string]genreNode),
+		aliases: make(map[string]string),
+	}
+	if err := tree.addNodes(file.Tree, ""); err != nil {
+		return nil, err
+	}
+	for alias, target := range file.Aliases {
+		tree.aliases[normalizeGenreKey(alias)] = normalizeGenreKey(target)
+	}
+
+	return tree, nil
+}
+
+func (t *GenreTree) addNodes(children map[string]json.RawMessage, parent string) error {
+	for name, raw := range children {
+		name = strings.TrimSpace(name)
+		key := normalizeGenreKey(name)
+		if key == "" {
+			continue
+		}
+		t.nodes[key] = genreNode{Name: name, Parent: parent}
+
+		var nested map[string]json.RawMessage
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &nested); err != nil {
+				return fmt.Errorf("invalid genre tree entry %q: %w", name, err)
+			}
+		}
+		if err := t.addNodes(nested, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *GenreTree) merge(other *GenreTree) {
+	for key, node := range other.nodes {
+		t.nodes[key] = node
+	}
+	for alias, target := range other.aliases {
+		t.aliases[alias] = target
+	}
+}
+
+func (t *GenreTree) resolve(raw string) (string, bool) {
+	key := normalizeGenreKey(raw)
+	if key == "" {
+		return "", false
+	}
+	if target, ok := t.aliases[key]; ok {
+		key = target
+	}
+	if _, ok := t.nodes[key]; ok {
+		return key, true
+	}
+	return key, false
+}
+
+func (t *GenreTree) root(key string) string {
+	for depth := 0; depth < 32; depth++ {
+		node, ok := t.nodes[key]
+		if !ok || node.Parent == "" {
+			return key
+		}
+		key = node.Parent
+	}
+	return key
+}
+
+func getUserGenreTreePath() (string, error) {
+	appDir, err := GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, userGenreTreeFile), nil
+}
+
+func LoadGenreTree() *GenreTree {
+	genreTreeMu.Lock()
+	defer genreTreeMu.Unlock()
+
+	var userMtime time.Time
+	userPath, err := getUserGenreTreePath()
+	if err == nil {
+		if info, statErr := os.Stat(userPath); statErr == nil {
+			userMtime = info.ModTime()
+		}
+	}
+
+	if genreTreeCache != nil && userMtime.Equal(genreTreeUserMtime) {
+		return genreTreeCache
+	}
+
+	tree, err := parseGenreTree(bundledGenreTree)
+	if err != nil {
+		fmt.Printf("[Genre] Failed to load bundled genre tree: %v\n", err)
+		tree = &GenreTree{nodes: map[string]genreNode{}, aliases: map[string]string{}}
+	}
+
+	if !userMtime.IsZero() {
+		if data, readErr := os.ReadFile(userPath); readErr == nil {
+			if userTree, parseErr := parseGenreTree(data); parseErr == nil {
+				tree.merge(userTree)
+			} else {
+				fmt.Printf("[Genre] Ignoring user genre tree %s: %v\n", userPath, parseErr)
+			}
+		}
+	}
+
+	genreTreeCache = tree
+	genreTreeUserMtime = userMtime
+	return tree
+}
+
+func CanonicalizeGenres(rawGenres []string, limit int) []string {
+	tree := LoadGenreTree()
+	whitelist := GetGenreWhitelistSetting()
+	collapse := GetGenreCollapseToParentSetting()
+	caser := cases.Title(language.English)
+
+	seen := make(map[string]struct{})
+	result := make([]string, 0, len(rawGenres))
+	for _, raw := range rawGenres {
+		key, known := tree.resolve(raw)
+		if key == "" {
+			continue
+		}
+
+		name := ""
+		if known {
+			if collapse {
+				key = tree.root(key)
+			}
+			name = tree.nodes[key].Name
+		} else if !whitelist {
+			name = caser.String(strings.TrimSpace(raw))
+		}
+		if name == "" {
+			continue
+		}
+
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, name)
+
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result
+}
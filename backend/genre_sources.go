@@ -0,0 +1,220 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	genreSourceMusicBrainz = "musicbrainz"
+	genreSourceSpotify     = "spotify"
+	genreSourceQobuz       = "qobuz"
+
+	genreSourceModeFallback = "fallback"
+	genreSourceModeMerge    = "merge"
+
+	defaultGenreSources = "musicbrainz-spotify-qobuz"
+)
+
+var (
+	spotifyArtistGenreCache sync.Map
+	spotifyTrackArtistCache sync.Map
+	qobuzISRCGenreCache     sync.Map
+)
+
+type GenreSourceInput struct {
+	MusicBrainz    string
+	SpotifyTrackID string
+	Qobuz          string
+	ISRC           string
+	TrackName      string
+	ArtistName     string
+	AlbumName      string
+}
+
+type spotifyTrackArtist struct {
+	GID  string `json:"gid"`
+	Name string `json:"name"`
+}
+
+type spotifyTrackArtistsRawData struct {
+	Artist   []spotifyTrackArtist `json:"artist"`
+	Explicit bool                 `json:"explicit"`
+}
+
+type spotifyArtistRawData struct {
+	Genre []string `json:"genre"`
+}
+
+func sanitizeGenreSourcesValue(value interface{}) []string {
+	rawSources, _ := value.(string)
+	allowed := map[string]struct{}{
+		genreSourceMusicBrainz: {},
+		genreSourceSpotify:     {},
+		genreSourceQobuz:       {},
+	}
+
+	seen := make(map[string]struct{})
+	sources := make([]string, 0, len(allowed))
+	for _, rawPart := range strings.Split(strings.TrimSpace(strings.ToLower(rawSources)), "-") {
+		part := strings.TrimSpace(rawPart)
+		if _, ok := allowed[part]; !ok {
+			continue
+		}
+		if _, ok := seen[part]; ok {
+			continue
+		}
+		seen[part] = struct{}{}
+		sources = append(sources, part)
+	}
+
+	if len(sources) == 0 {
+		return strings.Split(defaultGenreSources, "-")
+	}
+
+	return sources
+}
+
+func fetchSpotifyTrackArtists(httpClient *http.Client, trackID string) ([]spotifyTrackArtist, error) {
+	if cached, ok := spotifyTrackArtistCache.Load(trackID); ok {
+		return cached.([]spotifyTrackArtist), nil
+	}
+
+	payload, err := fetchSpotifyTrackRawData(httpClient, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Spotify track metadata: %w", err)
+	}
+
+	var track spotifyTrackArtistsRawData
+	if err := json.Unmarshal(payload, &track); err != nil {
+		return nil, fmt.Errorf("failed to decode Spotify track metadata: %w", err)
+	}
+
+	spotifyTrackArtistCache.Store(trackID, track.Artist)
+	spotifyTrackExplicitCache.Store(trackID, track.Explicit)
+	return track.Artist, nil
+}
+
+func FetchSpotifyArtistGenres(spotifyTrackID string) ([]string, error) {
+	trackID, err := extractSpotifyTrackID(spotifyTrackID)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	artists, err := fetchSpotifyTrackArtists(httpClient, trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	var genres []string
+	for _, artist := range artists {
+		gid := strings.TrimSpace(artist.GID)
+		if gid == "" {
+			continue
+		}
+
+		if cached, ok := spotifyArtistGenreCache.Load(gid); ok {
+			genres = append(genres, cached.([]string)...)
+			continue
+		}
+
+		artistPayload, err := fetchSpotifyRawMetadataByGID(httpClient, "artist", gid)
+		if err != nil {
+			fmt.Printf("[Genre] Failed to fetch Spotify artist %s: %v\n", artist.Name, err)
+			continue
+		}
+
+		var artistData spotifyArtistRawData
+		if err := json.Unmarshal(artistPayload, &artistData); err != nil {
+			continue
+		}
+
+		spotifyArtistGenreCache.Store(gid, artistData.Genre)
+		genres = append(genres, artistData.Genre...)
+	}
+
+	if len(genres) == 0 {
+		return nil, fmt.Errorf("no Spotify artist genres found for track %s", trackID)
+	}
+
+	return genres, nil
+}
+
+func collectGenreSource(source string, input GenreSourceInput) []string {
+	switch source {
+	case genreSourceMusicBrainz:
+		return SplitMetadataValues(input.MusicBrainz, GetSeparator())
+	case genreSourceSpotify:
+		if strings.TrimSpace(input.SpotifyTrackID) == "" {
+			return nil
+		}
+		genres, err := FetchSpotifyArtistGenres(input.SpotifyTrackID)
+		if err != nil {
+			fmt.Printf("[Genre] Spotify genre lookup failed: %v\n", err)
+			return nil
+		}
+		return genres
+	case genreSourceQobuz:
+		if genre := strings.TrimSpace(input.Qobuz); genre != "" {
+			return []string{genre}
+		}
+		if genre := fetchQobuzGenreByISRC(input); genre != "" {
+			return []string{genre}
+		}
+	}
+
+	return nil
+}
+
+func fetchQobuzGenreByISRC(input GenreSourceInput) string {
+	isrc := strings.ToUpper(strings.TrimSpace(input.ISRC))
+	if isrc == "" {
+		return ""
+	}
+	if cached, ok := qobuzISRCGenreCache.Load(isrc); ok {
+		return cached.(string)
+	}
+
+	track, err := NewQobuzDownloader().searchByISRC(isrc, input.TrackName, input.ArtistName, input.AlbumName)
+	if err != nil || track == nil {
+		fmt.Printf("[Genre] Qobuz genre lookup failed for %s: %v\n", isrc, err)
+		return ""
+	}
+
+	genre := strings.TrimSpace(track.Album.Genre.Name)
+	qobuzISRCGenreCache.Store(isrc, genre)
+	return genre
+}
+
+func ResolveGenreFromSources(input GenreSourceInput, useSingleGenre bool, embedGenre bool) string {
+	if !embedGenre {
+		return ""
+	}
+
+	limit := defaultGenreCount
+	if useSingleGenre {
+		limit = 1
+	}
+	merge := GetGenreSourceModeSetting() == genreSourceModeMerge
+
+	var collected []string
+	for _, source := range GetGenreSourcesSetting() {
+		genres := CanonicalizeGenres(collectGenreSource(source, input), 0)
+		if len(genres) == 0 {
+			continue
+		}
+
+		fmt.Printf("[Genre] %s: %s\n", source, strings.Join(genres, ", "))
+		collected = append(collected, genres...)
+		if !merge {
+			break
+		}
+	}
+
+	return strings.Join(CanonicalizeGenres(collected, limit), GetSeparator())
+}
@@ -3,6 +3,7 @@ package backend
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
 	"time"
@@ -137,6 +138,39 @@ func GetHistoryItems(appName string) ([]HistoryItem, error) {
 	return items, err
 }
 
+func FindHistoryPathBySpotifyID(spotifyID string, appName string) string {
+	if spotifyID == "" {
+		return ""
+	}
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return ""
+		}
+	}
+
+	found := ""
+	historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(historyBucket))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var item HistoryItem
+			if err := json.Unmarshal(v, &item); err != nil || item.SpotifyID != spotifyID || item.Path == "" {
+				continue
+			}
+			if info, err := os.Stat(item.Path); err == nil && !info.IsDir() {
+				found = item.Path
+				return nil
+			}
+		}
+		return nil
+	})
+	return found
+}
+
 func ClearHistory(appName string) error {
 	if historyDB == nil {
 		if err := InitHistoryDB(appName); err != nil {
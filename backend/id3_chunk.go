@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	pathfilepath "path/filepath"
+	"slices"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+)
+
+type audioChunkContainer struct {
+	order   binary.ByteOrder
+	id3ID   string
+	formIDs []string
+}
+
+type audioChunk struct {
+	id     string
+	offset int64
+	size   int64
+}
+
+var audioChunkContainers = map[string]audioChunkContainer{
+	"RIFF": {order: binary.LittleEndian, id3ID: "id3 ", formIDs: []string{"WAVE"}},
+	"FORM": {order: binary.BigEndian, id3ID: "ID3 ", formIDs: []string{"AIFF", "AIFC"}},
+}
+
+func readAudioChunks(f *os.File) (audioChunkContainer, []byte, []audioChunk, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return audioChunkContainer{}, nil, nil, err
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return audioChunkContainer{}, nil, nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+
+	container, ok := audioChunkContainers[string(header[:4])]
+	if !ok || !slices.Contains(container.formIDs, string(header[8:12])) {
+		return audioChunkContainer{}, nil, nil, fmt.Errorf("not a WAV or AIFF file")
+	}
+
+	var chunks []audioChunk
+	chunkHeader := make([]byte, 8)
+	for offset := int64(12); offset+8 <= info.Size(); {
+		if _, err := f.ReadAt(chunkHeader, offset); err != nil {
+			return container, nil, nil, fmt.Errorf("failed to read chunk header: %w", err)
+		}
+
+		size := int64(container.order.Uint32(chunkHeader[4:8]))
+		total := 8 + size + size%2
+		if offset+total > info.Size() {
+			total = info.Size() - offset
+		}
+
+		chunks = append(chunks, audioChunk{id: string(chunkHeader[:4]), offset: offset, size: total})
+		offset += total
+	}
+
+	return container, header, chunks, nil
+}
+
+func updateChunkID3Tag(filePath string, apply func(tag *id3v2.Tag)) error {
+	in, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer in.Close()
+
+	container, header, chunks, err := readAudioChunks(in)
+	if err != nil {
+		return err
+	}
+
+	tag := id3v2.NewEmptyTag()
+	for _, chunk := range chunks {
+		if !strings.EqualFold(chunk.id, container.id3ID) {
+			continue
+		}
+		existing, err := id3v2.ParseReader(io.NewSectionReader(in, chunk.offset+8, chunk.size-8), id3v2.Options{Parse: true})
+		if err == nil {
+			tag = existing
+		}
+		break
+	}
+	apply(tag)
+
+	var tagData bytes.Buffer
+	if _, err := tag.WriteTo(&tagData); err != nil {
+		return fmt.Errorf("failed to encode ID3 tag: %w", err)
+	}
+
+	tmpPath := strings.TrimSuffix(filePath, pathfilepath.Ext(filePath)) + ".tmp" + pathfilepath.Ext(filePath)
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if _, err := os.Stat(tmpPath); err == nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := bufio.NewWriter(out)
+	written := int64(len(header))
+	writeErr := func() error {
+		if _, err := writer.Write(header); err != nil {
+			return err
+		}
+
+		for _, chunk := range chunks {
+			if strings.EqualFold(chunk.id, container.id3ID) {
+				continue
+			}
+			n, err := io.Copy(writer, io.NewSectionReader(in, chunk.offset, chunk.size))
+			if err != nil {
+				return err
+			}
+			written += n
+		}
+
+		chunkHeader := make([]byte, 8)
+		copy(chunkHeader, container.id3ID)
+		container.order.PutUint32(chunkHeader[4:], uint32(tagData.Len()))
+		if tagData.Len()%2 == 1 {
+			tagData.WriteByte(0)
+		}
+		if _, err := writer.Write(chunkHeader); err != nil {
+			return err
+		}
+		if _, err := writer.Write(tagData.Bytes()); err != nil {
+			return err
+		}
+		written += int64(len(chunkHeader) + tagData.Len())
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		formSize := make([]byte, 4)
+		container.order.PutUint32(formSize, uint32(written-8))
+		_, err := out.WriteAt(formSize, 4)
+		return err
+	}()
+	if closeErr := out.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write audio file: %w", writeErr)
+	}
+
+	in.Close()
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to replace original file: %w", err)
+	}
+	return nil
+}
+
+func embedMetadataToID3Chunk(filePath string, metadata Metadata, coverPath string) error {
+	return updateChunkID3Tag(filePath, func(tag *id3v2.Tag) {
+		applyID3Metadata(tag, filePath, metadata, coverPath)
+	})
+}
+
+func embedLyricsToID3Chunk(filePath string, lyrics string) error {
+	return updateChunkID3Tag(filePath, func(tag *id3v2.Tag) {
+		applyID3Lyrics(tag, lyrics)
+	})
+}
+
+func embedCoverToID3Chunk(filePath string, coverPath string) error {
+	artwork, err := os.ReadFile(coverPath)
+	if err != nil {
+		return fmt.Errorf("failed to read cover art: %w", err)
+	}
+
+	return updateChunkID3Tag(filePath, func(tag *id3v2.Tag) {
+		tag.DeleteFrames(tag.CommonID("Attached picture"))
+		tag.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    artworkMimeType(artwork),
+			PictureType: id3v2.PTFrontCover,
+			Description: "Front cover",
+			Picture:     artwork,
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	defaultCoverJPEGQuality = 90
+	minCoverJPEGQuality     = 50
+	coverShrinkFactor       = 0.85
+	minCoverDimension       = 300
+)
+
+type CoverImageOptions struct {
+	MaxDimension int
+	Quality      int
+	MaxKB        int
+	ForceJPEG    bool
+}
+
+func embeddedCoverImageOptions() CoverImageOptions {
+	return CoverImageOptions{
+		MaxDimension: GetEmbeddedCoverMaxSizeSetting(),
+		Quality:      GetCoverJPEGQualitySetting(),
+		MaxKB:        GetEmbeddedCoverMaxKBSetting(),
+		ForceJPEG:    true,
+	}
+}
+
+func sidecarCoverImageOptions() CoverImageOptions {
+	return CoverImageOptions{
+		Quality:   GetCoverJPEGQualitySetting(),
+		MaxKB:     GetSidecarCoverMaxKBSetting(),
+		ForceJPEG: true,
+	}
+}
+
+func isProgressiveJPEG(data []byte) bool {
+	for i := 2; i+3 < len(data); {
+		if data[i] != 0xFF {
+			return false
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xC2:
+			return true
+		case marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			return false
+		case marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01:
+			i += 2
+			continue
+		case marker == 0xDA:
+			return false
+		}
+		i += 2 + int(data[i+2])<<8 + int(data[i+3])
+	}
+	return false
+}
+
+func fitImageDimensions(width, height, maxDimension int) (int, int) {
+	if maxDimension <= 0 || (width <= maxDimension && height <= maxDimension) {
+		return width, height
+	}
+	if width >= height {
+		return maxDimension, max(1, height*maxDimension/width)
+	}
+	return max(1, width*maxDimension/height), maxDimension
+}
+
+func scaleImage(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	sameSize := bounds.Dx() == width && bounds.Dy() == height
+	if opaque, ok := src.(interface{ Opaque() bool }); ok && opaque.Opaque() && sameSize {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.Draw(dst, dst.Bounds(), image.White, image.Point{}, xdraw.Src)
+	if sameSize {
+		xdraw.Draw(dst, dst.Bounds(), src, bounds.Min, xdraw.Over)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, xdraw.Over, nil)
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func ProcessCoverImage(data []byte, opts CoverImageOptions) ([]byte, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover image: %w", err)
+	}
+
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = defaultCoverJPEGQuality
+	}
+	maxBytes := opts.MaxKB * 1024
+	width, height := fitImageDimensions(config.Width, config.Height, opts.MaxDimension)
+
+	needsEncode := width != config.Width || height != config.Height
+	if format == "jpeg" {
+		needsEncode = needsEncode || isProgressiveJPEG(data)
+	} else {
+		needsEncode = needsEncode || opts.ForceJPEG
+	}
+	if maxBytes > 0 && len(data) > maxBytes {
+		needsEncode = true
+	}
+	if !needsEncode {
+		return data, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover image: %w", err)
+	}
+
+	for {
+		scaled := scaleImage(src, width, height)
+		encoded, err := encodeJPEG(scaled, quality)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cover image: %w", err)
+		}
+		if maxBytes <= 0 || len(encoded) <= maxBytes {
+			return encoded, nil
+		}
+
+		for q := quality - 10; q >= minCoverJPEGQuality; q -= 10 {
+			if encoded, err = encodeJPEG(scaled, q); err == nil && len(encoded) <= maxBytes {
+				return encoded, nil
+			}
+		}
+
+		if max(width, height) <= minCoverDimension {
+			return encoded, nil
+		}
+		width = max(1, int(float64(width)*coverShrinkFactor))
+		height = max(1, int(float64(height)*coverShrinkFactor))
+	}
+}
+
+func ProcessCoverImageFile(inputPath, outputPath string, opts CoverImageOptions) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read cover image: %w", err)
+	}
+
+	processed, err := ProcessCoverImage(data, opts)
+	if err != nil {
+		return err
+	}
+	if outputPath == inputPath && bytes.Equal(processed, data) {
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, processed, 0644); err != nil {
+		return fmt.Errorf("failed to write cover image: %w", err)
+	}
+	return nil
+}
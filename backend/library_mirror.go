@@ -0,0 +1,568 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	mirrorManifestName = ".spotiflac-mirror.json"
+
+	mirrorChangeDetectionMtime    = "mtime"
+	mirrorChangeDetectionChecksum = "checksum"
+
+	mirrorActionConverted = "converted"
+	mirrorActionCopied    = "copied"
+	mirrorActionSkipped   = "skipped"
+	mirrorActionRewritten = "rewritten"
+	mirrorActionDeleted   = "deleted"
+	mirrorActionFailed    = "failed"
+	mirrorActionCancelled = "cancelled"
+
+	mirrorCopyFingerprint     = "copy"
+	mirrorPlaylistFingerprint = "playlist"
+)
+
+var mirrorAudioExtensions = map[string]bool{
+	".flac": true,
+	".mp3":  true,
+	".m4a":  true,
+	".aac":  true,
+	".opus": true,
+	".ogg":  true,
+	".wav":  true,
+	".aiff": true,
+	".aif":  true,
+}
+
+var mirrorLossyExtensions = map[string]bool{
+	".mp3":  true,
+	".m4a":  true,
+	".aac":  true,
+	".opus": true,
+	".ogg":  true,
+}
+
+var mirrorPlaylistExtensions = map[string]bool{
+	".m3u":  true,
+	".m3u8": true,
+}
+
+var coverSidecarNames = []string{"cover.jpg", "folder.jpg", "cover.png", "folder.png"}
+
+type MirrorLibraryRequest struct {
+	SourceRoot      string `json:"source_root"`
+	TargetRoot      string `json:"target_root"`
+	Preset          string `json:"preset"`
+	ChangeDetection string `json:"change_detection,omitempty"`
+	DeleteOrphans   bool   `json:"delete_orphans"`
+	JobID           string `json:"job_id,omitempty"`
+}
+
+type MirrorLibraryFileResult struct {
+	Source string `json:"source,omitempty"`
+	Target string `json:"target"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+type MirrorLibrarySummary struct {
+	Converted int                       `json:"converted"`
+	Copied    int                       `json:"copied"`
+	Skipped   int                       `json:"skipped"`
+	Rewritten int                       `json:"rewritten"`
+	Deleted   int                       `json:"deleted"`
+	Failed    int                       `json:"failed"`
+	Cancelled bool                      `json:"cancelled"`
+	Results   []MirrorLibraryFileResult `json:"results"`
+}
+
+type mirrorManifestEntry struct {
+	Size        int64  `json:"size"`
+	ModTime     int64  `json:"mod_time"`
+	Checksum    string `json:"checksum,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Output      string `json:"output"`
+}
+
+type mirrorManifest struct {
+	Files map[string]mirrorManifestEntry `json:"files"`
+}
+
+type mirrorTask struct {
+	source  string
+	target  string
+	key     string
+	convert bool
+	info    os.FileInfo
+}
+
+func loadMirrorManifest(targetRoot string) *mirrorManifest {
+	manifest := &mirrorManifest{Files: map[string]mirrorManifestEntry{}}
+	data, err := os.ReadFile(filepath.Join(targetRoot, mirrorManifestName))
+	if err != nil {
+		return manifest
+	}
+	if err := json.Unmarshal(data, manifest); err != nil || manifest.Files == nil {
+		fmt.Printf("[Mirror] Ignoring unreadable manifest in %s\n", targetRoot)
+		return &mirrorManifest{Files: map[string]mirrorManifestEntry{}}
+	}
+	return manifest
+}
+
+func (m *mirrorManifest) save(targetRoot string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(targetRoot, mirrorManifestName), data, 0644)
+}
+
+func mirrorAudioChecksum(path string) (string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".flac") {
+		if md5 := flacStreamInfoMD5(path); md5 != "" {
+			return "md5:" + md5, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isMirrorLossySource(path, ext string) bool {
+	if !mirrorLossyExtensions[ext] {
+		return false
+	}
+	if ext == ".m4a" {
+		info, err := readNativeAudioInfo(path)
+		return err != nil || info.Codec != "alac"
+	}
+	return true
+}
+
+func isMirrorKeyUnreadable(key string, unreadable []string) bool {
+	for _, prefix := range unreadable {
+		if prefix == "." || key == prefix || strings.HasPrefix(key, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func removeEmptyMirrorDirs(dir, targetRoot string) {
+	for dir != targetRoot && strings.HasPrefix(dir, targetRoot+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func isCoverSidecar(name string) bool {
+	for _, sidecar := range coverSidecarNames {
+		if strings.EqualFold(name, sidecar) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyMirrorFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := dst + ".part"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
+func rewriteMirrorPlaylist(data []byte, playlistDir string, outputs map[string]string) []byte {
+	var out bytes.Buffer
+	lines := strings.SplitAfter(string(data), "\n")
+	for _, line := range lines {
+		entry := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(entry)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.Contains(trimmed, "://") {
+			out.WriteString(line)
+			continue
+		}
+
+		source := filepath.FromSlash(trimmed)
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(playlistDir, source)
+		}
+		target, ok := outputs[filepath.Clean(source)]
+		if !ok {
+			out.WriteString(line)
+			continue
+		}
+
+		if filepath.IsAbs(filepath.FromSlash(trimmed)) {
+			out.WriteString(target)
+		} else {
+			out.WriteString(strings.TrimSuffix(trimmed, filepath.Ext(trimmed)) + filepath.Ext(target))
+		}
+		out.WriteString(line[len(entry):])
+	}
+	return out.Bytes()
+}
+
+func (t mirrorTask) upToDate(entry mirrorManifestEntry, ok bool, fingerprint, changeDetection string) (bool, string) {
+	if !ok || entry.Fingerprint != fingerprint || entry.Output != t.target || !fileExists(t.target) {
+		return false, ""
+	}
+	if entry.Size == t.info.Size() && entry.ModTime == t.info.ModTime().UnixNano() {
+		return true, entry.Checksum
+	}
+	if changeDetection != mirrorChangeDetectionChecksum || entry.Checksum == "" {
+		return false, ""
+	}
+
+	checksum, err := mirrorAudioChecksum(t.source)
+	if err != nil {
+		return false, ""
+	}
+	return checksum == entry.Checksum, checksum
+}
+
+func (s *MirrorLibrarySummary) add(result MirrorLibraryFileResult) {
+	switch result.Action {
+	case mirrorActionConverted:
+		s.Converted++
+	case mirrorActionCopied:
+		s.Copied++
+	case mirrorActionSkipped:
+		s.Skipped++
+	case mirrorActionRewritten:
+		s.Rewritten++
+	case mirrorActionDeleted:
+		s.Deleted++
+	case mirrorActionFailed:
+		s.Failed++
+	case mirrorActionCancelled:
+		s.Cancelled = true
+	}
+	s.Results = append(s.Results, result)
+}
+
+func MirrorLibrary(req MirrorLibraryRequest, progress func(AudioJobProgress)) (*MirrorLibrarySummary, error) {
+	sourceRoot := strings.TrimSpace(req.SourceRoot)
+	targetRoot := strings.TrimSpace(req.TargetRoot)
+	if sourceRoot == "" || targetRoot == "" {
+		return nil, fmt.Errorf("source and target folders are required")
+	}
+	sourceRoot = filepath.Clean(NormalizePath(sourceRoot))
+	targetRoot = filepath.Clean(NormalizePath(targetRoot))
+	if sourceRoot == targetRoot {
+		return nil, fmt.Errorf("source and target folders must be different")
+	}
+	if rel, err := filepath.Rel(targetRoot, sourceRoot); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("source folder must not be inside the target folder")
+	}
+	if info, err := os.Stat(sourceRoot); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("source folder does not exist: %s", sourceRoot)
+	}
+
+	preset, ok := FindConversionPreset(req.Preset)
+	if !ok {
+		return nil, fmt.Errorf("conversion preset not found: %s", req.Preset)
+	}
+	presetData, _ := json.Marshal(preset)
+	fingerprint := string(presetData)
+
+	changeDetection := strings.ToLower(strings.TrimSpace(req.ChangeDetection))
+	if changeDetection != mirrorChangeDetectionChecksum {
+		changeDetection = mirrorChangeDetectionMtime
+	}
+
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ffmpeg path: %w", err)
+	}
+	if err := ValidateExecutable(ffmpegPath); err != nil {
+		return nil, fmt.Errorf("invalid ffmpeg executable: %w", err)
+	}
+
+	if err := os.MkdirAll(targetRoot, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target folder: %w", err)
+	}
+
+	outputExt := "." + preset.Format
+	var tasks []mirrorTask
+	var playlists []mirrorTask
+	var collisions []MirrorLibraryFileResult
+	var unreadable []string
+	outputs := map[string]string{}
+	claimed := map[string]string{}
+
+	err = filepath.Walk(sourceRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if rel, relErr := filepath.Rel(sourceRoot, path); relErr == nil {
+				unreadable = append(unreadable, filepath.ToSlash(rel))
+			}
+			fmt.Printf("[Mirror] Warning: Failed to read %s: %v\n", path, err)
+			return nil
+		}
+		if info.IsDir() {
+			if path == targetRoot {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(sourceRoot, path)
+		if err != nil {
+			return nil
+		}
+		task := mirrorTask{
+			source: path,
+			target: filepath.Join(targetRoot, rel),
+			key:    filepath.ToSlash(rel),
+			info:   info,
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		isAudio := mirrorAudioExtensions[ext]
+		switch {
+		case isAudio:
+			if !isMirrorLossySource(path, ext) {
+				task.convert = true
+				task.target = strings.TrimSuffix(task.target, filepath.Ext(task.target)) + outputExt
+			}
+		case mirrorPlaylistExtensions[ext]:
+			playlists = append(playlists, task)
+			return nil
+		case !isCoverSidecar(info.Name()):
+			return nil
+		}
+
+		targetKey := strings.ToLower(task.target)
+		if other, ok := claimed[targetKey]; ok {
+			fmt.Printf("[Mirror] Skipping %s: output %s already used by %s\n", path, task.target, other)
+			collisions = append(collisions, MirrorLibraryFileResult{
+				Source: path,
+				Target: task.target,
+				Action: mirrorActionFailed,
+				Error:  fmt.Sprintf("output collides with %s", other),
+			})
+			return nil
+		}
+		claimed[targetKey] = path
+		if isAudio {
+			outputs[path] = task.target
+		}
+		tasks = append(tasks, task)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk source folder: %w", err)
+	}
+
+	fmt.Printf("[Mirror] %s -> %s using preset %s (%d files, %s change detection)\n", sourceRoot, targetRoot, preset.Name, len(tasks), changeDetection)
+
+	manifest := loadMirrorManifest(targetRoot)
+	results := make([]MirrorLibraryFileResult, len(tasks))
+	entries := make([]*mirrorManifestEntry, len(tasks))
+
+	job := startAudioJob(req.JobID, len(tasks), progress)
+	defer job.finish()
+
+	job.run(func(idx int) {
+		task := tasks[idx]
+		result := MirrorLibraryFileResult{Source: task.source, Target: task.target}
+		defer func() {
+			results[idx] = result
+		}()
+
+		if job.cancelled() {
+			result.Action = mirrorActionCancelled
+			job.report(idx, task.source, audioJobStatusCancelled, 0)
+			return
+		}
+
+		taskFingerprint := mirrorCopyFingerprint
+		if task.convert {
+			taskFingerprint = fingerprint
+		}
+
+		previous, hasPrevious := manifest.Files[task.key]
+		current, checksum := task.upToDate(previous, hasPrevious, taskFingerprint, changeDetection)
+		if current {
+			result.Action = mirrorActionSkipped
+			entries[idx] = &mirrorManifestEntry{
+				Size:        task.info.Size(),
+				ModTime:     task.info.ModTime().UnixNano(),
+				Checksum:    checksum,
+				Fingerprint: taskFingerprint,
+				Output:      task.target,
+			}
+			job.report(idx, task.source, audioJobStatusDone, 100)
+			return
+		}
+		job.report(idx, task.source, audioJobStatusRunning, 0)
+
+		err := os.MkdirAll(filepath.Dir(task.target), 0755)
+		if err == nil && task.convert {
+			err = convertAudioFile(job.ctx, ffmpegPath, task.source, task.target, preset, func(percent float64) {
+				job.report(idx, task.source, audioJobStatusRunning, percent)
+			})
+			if err != nil && job.statusForError(err) != audioJobStatusCancelled {
+				os.Remove(task.target)
+			}
+		} else if err == nil {
+			err = copyMirrorFile(task.source, task.target)
+		}
+
+		if err != nil {
+			status := job.statusForError(err)
+			if status == audioJobStatusCancelled {
+				result.Action = mirrorActionCancelled
+			} else {
+				result.Action = mirrorActionFailed
+				result.Error = err.Error()
+				fmt.Printf("[Mirror] Failed: %s: %v\n", task.source, err)
+			}
+			job.report(idx, task.source, status, 0)
+			return
+		}
+
+		if changeDetection == mirrorChangeDetectionChecksum && checksum == "" {
+			checksum, _ = mirrorAudioChecksum(task.source)
+		}
+		entries[idx] = &mirrorManifestEntry{
+			Size:        task.info.Size(),
+			ModTime:     task.info.ModTime().UnixNano(),
+			Checksum:    checksum,
+			Fingerprint: taskFingerprint,
+			Output:      task.target,
+		}
+		if task.convert {
+			result.Action = mirrorActionConverted
+		} else {
+			result.Action = mirrorActionCopied
+		}
+		job.report(idx, task.source, audioJobStatusDone, 100)
+	})
+
+	summary := &MirrorLibrarySummary{Results: make([]MirrorLibraryFileResult, 0, len(tasks)+len(collisions)+len(playlists))}
+	next := &mirrorManifest{Files: map[string]mirrorManifestEntry{}}
+	expected := map[string]bool{}
+	for idx, task := range tasks {
+		summary.add(results[idx])
+		expected[task.target] = true
+		if entries[idx] != nil {
+			next.Files[task.key] = *entries[idx]
+		}
+	}
+	for _, collision := range collisions {
+		summary.add(collision)
+	}
+
+	for _, playlist := range playlists {
+		expected[playlist.target] = true
+		result := MirrorLibraryFileResult{Source: playlist.source, Target: playlist.target, Action: mirrorActionSkipped}
+		data, err := os.ReadFile(playlist.source)
+		if err == nil {
+			rewritten := rewriteMirrorPlaylist(data, filepath.Dir(playlist.source), outputs)
+			existing, readErr := os.ReadFile(playlist.target)
+			if readErr != nil || !bytes.Equal(existing, rewritten) {
+				if err = os.MkdirAll(filepath.Dir(playlist.target), 0755); err == nil {
+					err = os.WriteFile(playlist.target, rewritten, 0644)
+				}
+				result.Action = mirrorActionRewritten
+			}
+		}
+		if err != nil {
+			result.Action = mirrorActionFailed
+			result.Error = err.Error()
+		} else {
+			next.Files[playlist.key] = mirrorManifestEntry{
+				Size:        playlist.info.Size(),
+				ModTime:     playlist.info.ModTime().UnixNano(),
+				Fingerprint: mirrorPlaylistFingerprint,
+				Output:      playlist.target,
+			}
+		}
+		summary.add(result)
+	}
+
+	deleteOrphans := req.DeleteOrphans && !summary.Cancelled
+	previousKeys := make([]string, 0, len(manifest.Files))
+	for key := range manifest.Files {
+		previousKeys = append(previousKeys, key)
+	}
+	sort.Strings(previousKeys)
+	for _, key := range previousKeys {
+		entry := manifest.Files[key]
+		if _, ok := next.Files[key]; ok || entry.Output == "" {
+			continue
+		}
+		if expected[entry.Output] {
+			if summary.Cancelled && fileExists(entry.Output) {
+				next.Files[key] = entry
+			}
+			continue
+		}
+
+		rel, relErr := filepath.Rel(targetRoot, entry.Output)
+		inTarget := relErr == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		if !deleteOrphans || !inTarget || isMirrorKeyUnreadable(key, unreadable) {
+			if fileExists(entry.Output) {
+				next.Files[key] = entry
+			}
+			continue
+		}
+		if !fileExists(entry.Output) {
+			continue
+		}
+
+		result := MirrorLibraryFileResult{Source: filepath.Join(sourceRoot, filepath.FromSlash(key)), Target: entry.Output, Action: mirrorActionDeleted}
+		if err := os.Remove(entry.Output); err != nil {
+			result.Action = mirrorActionFailed
+			result.Error = err.Error()
+			next.Files[key] = entry
+		} else {
+			removeEmptyMirrorDirs(filepath.Dir(entry.Output), targetRoot)
+		}
+		summary.add(result)
+	}
+	if err := next.save(targetRoot); err != nil {
+		fmt.Printf("[Mirror] Warning: Failed to save manifest: %v\n", err)
+	}
+
+	fmt.Printf("[Mirror] Done: %d converted, %d copied, %d skipped, %d playlists rewritten, %d deleted, %d failed\n",
+		summary.Converted, summary.Copied, summary.Skipped, summary.Rewritten, summary.Deleted, summary.Failed)
+	return summary, nil
+}
@@ -1,7 +1,9 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -26,9 +29,10 @@ type LRCLibResponse struct {
 }
 
 type LyricsLine struct {
-	StartTimeMs string `json:"startTimeMs"`
-	Words       string `json:"words"`
-	EndTimeMs   string `json:"endTimeMs"`
+	StartTimeMs string           `json:"startTimeMs"`
+	Words       string           `json:"words"`
+	EndTimeMs   string           `json:"endTimeMs"`
+	Syllables   []LyricsSyllable `json:"syllables,omitempty"`
 }
 
 type LyricsResponse struct {
@@ -59,6 +63,7 @@ type LyricsDownloadResponse struct {
 	File          string `json:"file,omitempty"`
 	Error         string `json:"error,omitempty"`
 	AlreadyExists bool   `json:"already_exists,omitempty"`
+	Instrumental  bool   `json:"instrumental,omitempty"`
 }
 
 type LyricsClient struct {
@@ -72,6 +77,18 @@ func NewLyricsClient() *LyricsClient {
 }
 
 func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName, albumName string, duration int) (*LyricsResponse, error) {
+	lrcLibResp, err := c.fetchLRCLibGet(context.Background(), trackName, artistName, albumName, duration)
+	if err != nil {
+		return nil, err
+	}
+	if lrcLibResp.Instrumental && lrcLibResp.SyncedLyrics == "" && lrcLibResp.PlainLyrics == "" {
+		return nil, ErrLyricsInstrumental
+	}
+
+	return c.convertLRCLibToLyricsResponse(lrcLibResp), nil
+}
+
+func (c *LyricsClient) fetchLRCLibGet(ctx context.Context, trackName, artistName, albumName string, duration int) (*LRCLibResponse, error) {
 
 	apiURL := fmt.Sprintf("https://lrclib.net/api/get?artist_name=%s&track_name=%s",
 		url.QueryEscape(artistName),
@@ -85,7 +102,12 @@ func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName, albumName
 		apiURL = fmt.Sprintf("%s&duration=%d", apiURL, duration)
 	}
 
-	resp, err := c.httpClient.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LRCLIB request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from LRCLIB: %v", err)
 	}
@@ -105,11 +127,14 @@ func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName, albumName
 		return nil, fmt.Errorf("failed to parse LRCLIB response: %v", err)
 	}
 
+	if lrcLibResp.Instrumental {
+		return &lrcLibResp, nil
+	}
 	if lrcLibResp.SyncedLyrics == "" && lrcLibResp.PlainLyrics == "" {
 		return nil, fmt.Errorf("LRCLIB returned empty lyrics")
 	}
 
-	return c.convertLRCLibToLyricsResponse(&lrcLibResp), nil
+	return &lrcLibResp, nil
 }
 
 func (c *LyricsClient) convertLRCLibToLyricsResponse(lrcLib *LRCLibResponse) *LyricsResponse {
@@ -137,16 +162,20 @@ func (c *LyricsClient) convertLRCLibToLyricsResponse(lrcLib *LRCLibResponse) *Ly
 			continue
 		}
 
-		if strings.HasPrefix(line, "[") && len(line) > 10 {
+		if len(line) > 10 && lrcTimestampLinePattern.MatchString(line) {
 			closeBracket := strings.Index(line, "]")
 			if closeBracket > 0 {
 				timestamp := line[1:closeBracket]
-				words := strings.TrimSpace(line[closeBracket+1:])
-
 				ms := lrcTimestampToMs(timestamp)
+				words, syllables := parseEnhancedLRCWords(strings.TrimSpace(line[closeBracket+1:]), ms)
+				if len(syllables) > 0 {
+					resp.SyncType = lyricsSyncTypeWord
+				}
+
 				resp.Lines = append(resp.Lines, LyricsLine{
 					StartTimeMs: fmt.Sprintf("%d", ms),
 					Words:       words,
+					Syllables:   syllables,
 				})
 				continue
 			}
@@ -162,39 +191,35 @@ func (c *LyricsClient) convertLRCLibToLyricsResponse(lrcLib *LRCLibResponse) *Ly
 }
 
 func lrcTimestampToMs(timestamp string) int64 {
-	var minutes, seconds, centiseconds int64
-
-	n, _ := fmt.Sscanf(timestamp, "%d:%d.%d", &minutes, &seconds, &centiseconds)
-	if n >= 2 {
-		return minutes*60*1000 + seconds*1000 + centiseconds*10
+	minutePart, rest, ok := strings.Cut(strings.TrimSpace(timestamp), ":")
+	if !ok {
+		return 0
 	}
-	return 0
-}
-
-func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string) (*LyricsResponse, error) {
-
-	apiURL := fmt.Sprintf("https://lrclib.net/api/search?artist_name=%s&track_name=%s",
-		url.QueryEscape(artistName),
-		url.QueryEscape(trackName))
+	secondPart, fraction, _ := strings.Cut(strings.Replace(rest, ":", ".", 1), ".")
 
-	resp, err := c.httpClient.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
+	minutes, err1 := strconv.ParseInt(minutePart, 10, 64)
+	seconds, err2 := strconv.ParseInt(secondPart, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	ms := minutes*60*1000 + seconds*1000
+	if len(fraction) > 3 {
+		fraction = fraction[:3]
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read failed: %v", err)
+	if value, err := strconv.ParseInt(fraction, 10, 64); err == nil {
+		for i := len(fraction); i < 3; i++ {
+			value *= 10
+		}
+		ms += value
 	}
+	return ms
+}
 
-	var results []LRCLibResponse
-	if err := json.Unmarshal(body, &results); err != nil {
-		return nil, fmt.Errorf("parse failed: %v", err)
+func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string) (*LyricsResponse, error) {
+	results, err := c.fetchLRCLibSearch(context.Background(), trackName, artistName)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(results) == 0 {
@@ -230,7 +255,45 @@ func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string)
 	return c.convertLRCLibToLyricsResponse(best), nil
 }
 
+func (c *LyricsClient) fetchLRCLibSearch(ctx context.Context, trackName, artistName string) ([]LRCLibResponse, error) {
+
+	apiURL := fmt.Sprintf("https://lrclib.net/api/search?artist_name=%s&track_name=%s",
+		url.QueryEscape(artistName),
+		url.QueryEscape(trackName))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %v", err)
+	}
+
+	var results []LRCLibResponse
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("parse failed: %v", err)
+	}
+
+	return results, nil
+}
+
 func simplifyTrackName(name string) string {
+	if parsed := ParseTitleVersion(name); parsed.Title != strings.TrimSpace(name) {
+		name, _ = extractFeaturedFromTitle(parsed.Title)
+		return name
+	}
 
 	if idx := strings.Index(name, "("); idx > 0 {
 		name = strings.TrimSpace(name[:idx])
@@ -243,7 +306,7 @@ func simplifyTrackName(name string) string {
 }
 
 func isSynced(resp *LyricsResponse) bool {
-	return resp != nil && !resp.Error && resp.SyncType == "LINE_SYNCED" && len(resp.Lines) > 0
+	return resp != nil && !resp.Error && (resp.SyncType == lyricsSyncTypeLine || resp.SyncType == lyricsSyncTypeWord) && len(resp.Lines) > 0
 }
 
 func hasLyrics(resp *LyricsResponse) bool {
@@ -251,80 +314,24 @@ func hasLyrics(resp *LyricsResponse) bool {
 }
 
 func (c *LyricsClient) FetchLyricsAllSources(spotifyID, trackName, artistName, albumName string, duration int) (*LyricsResponse, string, error) {
-
-	var unsyncedFallback *LyricsResponse
-	var unsyncedSource string
-
-	check := func(resp *LyricsResponse, err error, source string) (*LyricsResponse, string, bool) {
-		if err != nil || resp == nil || resp.Error || len(resp.Lines) == 0 {
-			return nil, "", false
-		}
-		if isSynced(resp) {
-			return resp, source, true
-		}
-
-		if unsyncedFallback == nil {
-			unsyncedFallback = resp
-			unsyncedSource = source
-		}
-		return nil, "", false
-	}
-
-	var resp *LyricsResponse
-	var src string
-	var found bool
-
-	resp, _ = c.FetchLyricsWithMetadata(trackName, artistName, albumName, duration)
-	resp, src, found = check(resp, nil, "LRCLIB")
-	if found {
-		fmt.Printf("   [LRCLIB] Synced found via exact match (with album)\n")
-		return resp, src, nil
-	}
-	fmt.Printf("   LRCLIB exact (with album): no synced\n")
-
-	if albumName != "" {
-		resp, _ = c.FetchLyricsWithMetadata(trackName, artistName, "", duration)
-		resp, src, found = check(resp, nil, "LRCLIB (no album)")
-		if found {
-			fmt.Printf("   [LRCLIB] Synced found via exact match (no album)\n")
-			return resp, src, nil
-		}
-		fmt.Printf("   LRCLIB exact (no album): no synced\n")
-	}
-
-	resp, _ = c.FetchLyricsFromLRCLibSearch(trackName, artistName)
-	resp, src, found = check(resp, nil, "LRCLIB Search")
-	if found {
-		fmt.Printf("   [LRCLIB] Synced found via search\n")
-		return resp, src, nil
+	best, err := c.FetchBestLyrics(LyricsQuery{
+		SpotifyID:  spotifyID,
+		TrackName:  trackName,
+		ArtistName: artistName,
+		AlbumName:  albumName,
+		Duration:   duration,
+	})
+	if err != nil {
+		return nil, "", err
 	}
-	fmt.Printf("   LRCLIB search: no synced\n")
-
-	simplifiedTrack := simplifyTrackName(trackName)
-	if simplifiedTrack != trackName {
-		fmt.Printf("   Trying simplified name: %s\n", simplifiedTrack)
-
-		resp, _ = c.FetchLyricsWithMetadata(simplifiedTrack, artistName, albumName, duration)
-		resp, src, found = check(resp, nil, "LRCLIB (simplified)")
-		if found {
-			fmt.Printf("   [LRCLIB] Synced found via simplified exact\n")
-			return resp, src, nil
-		}
-
-		resp, _ = c.FetchLyricsFromLRCLibSearch(simplifiedTrack, artistName)
-		resp, src, found = check(resp, nil, "LRCLIB Search (simplified)")
-		if found {
-			fmt.Printf("   [LRCLIB] Synced found via simplified search\n")
-			return resp, src, nil
-		}
+	if best.Instrumental && !hasLyrics(best.Lyrics) {
+		return nil, best.Source, ErrLyricsInstrumental
 	}
 
-	if unsyncedFallback != nil {
-		fmt.Printf("   [LRCLIB] No synced found, using unsynced from: %s\n", unsyncedSource)
-		return unsyncedFallback, unsyncedSource + " (unsynced)", nil
+	if !isSynced(best.Lyrics) {
+		return best.Lyrics, best.Source + " (unsynced)", nil
 	}
-
-	return nil, "", fmt.Errorf("lyrics not found in any source")
+	return best.Lyrics, best.Source, nil
 }
 
 func (c *LyricsClient) ConvertToLRC(lyrics *LyricsResponse, trackName, artistName string) string {
@@ -364,7 +371,7 @@ func msToLRCTimestamp(msStr string) string {
 	return fmt.Sprintf("[%02d:%02d.%02d]", minutes, seconds, centiseconds)
 }
 
-func buildLyricsFilename(trackName, artistName, albumName, albumArtist, releaseDate, filenameFormat, isrc string, includeTrackNumber bool, position, discNumber int) string {
+func buildLyricsFilename(trackName, artistName, albumName, albumArtist, releaseDate, filenameFormat, isrc string, includeTrackNumber bool, position, discNumber int, extension string) string {
 	safeTitle := sanitizeFilename(trackName)
 	safeArtist := sanitizeFilename(artistName)
 	safeAlbum := sanitizeFilename(albumName)
@@ -418,7 +425,7 @@ func buildLyricsFilename(trackName, artistName, albumName, albumArtist, releaseD
 		}
 	}
 
-	return filename + ".lrc"
+	return filename + extension
 }
 
 func findAudioFileForLyrics(dir, trackName, artistName string) string {
@@ -492,7 +499,8 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 	if resolvedISRC == "" && strings.Contains(filenameFormat, "{isrc}") {
 		resolvedISRC = ResolveTrackISRC(req.SpotifyID)
 	}
-	filename := buildLyricsFilename(req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, filenameFormat, resolvedISRC, req.TrackNumber, req.Position, req.DiscNumber)
+	lyricsFormat := GetLyricsFormatSetting()
+	filename := buildLyricsFilename(req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, filenameFormat, resolvedISRC, req.TrackNumber, req.Position, req.DiscNumber, lyricsFormatExtension(lyricsFormat))
 	filePath := filepath.Join(outputDir, filename)
 
 	filePath, alreadyExists := ResolveOutputPathForDownload(filePath, GetRedownloadWithSuffixSetting())
@@ -516,22 +524,41 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 	}
 
 	lyrics, _, err := c.FetchLyricsAllSources(req.SpotifyID, req.TrackName, req.ArtistName, req.AlbumName, audioDuration)
-	if err != nil {
+	instrumental := errors.Is(err, ErrLyricsInstrumental)
+	if instrumental {
+		lyrics = InstrumentalLyrics(GetLyricsInstrumentalMarkerSetting())
+		if lyrics == nil {
+			return &LyricsDownloadResponse{
+				Success:      true,
+				Message:      "Track is instrumental",
+				Instrumental: true,
+			}, nil
+		}
+	} else if err != nil {
 		return &LyricsDownloadResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, err
 	}
 
-	lrcContent := c.ConvertToLRC(lyrics, req.TrackName, req.ArtistName)
+	lrcContent := c.FormatLyrics(lyrics, req.TrackName, req.ArtistName, lyricsFormat)
 
 	if err := os.WriteFile(filePath, []byte(lrcContent), 0644); err != nil {
 		return &LyricsDownloadResponse{
 			Success: false,
-			Error:   fmt.Sprintf("failed to write LRC file: %v", err),
+			Error:   fmt.Sprintf("failed to write lyrics file: %v", err),
 		}, err
 	}
 
+	if instrumental {
+		return &LyricsDownloadResponse{
+			Success:      true,
+			Message:      "Track is instrumental",
+			File:         filePath,
+			Instrumental: true,
+		}, nil
+	}
+
 	return &LyricsDownloadResponse{
 		Success: true,
 		Message: "Lyrics downloaded successfully",
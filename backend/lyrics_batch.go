@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	lyricsBatchStatusSynced       = "synced"
+	lyricsBatchStatusUnsynced     = "unsynced"
+	lyricsBatchStatusInstrumental = "instrumental"
+	lyricsBatchStatusMissing      = "missing"
+	lyricsBatchStatusSkipped      = "skipped"
+	lyricsBatchStatusFailed       = "failed"
+)
+
+type LyricsBatchRequest struct {
+	FolderPath string `json:"folder_path"`
+	Mode       string `json:"mode,omitempty"`
+	Overwrite  bool   `json:"overwrite,omitempty"`
+}
+
+type LyricsBatchFileResult struct {
+	File   string `json:"file"`
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty"`
+	Status string `json:"status"`
+	Source string `json:"source,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type LyricsBatchSummary struct {
+	Total        int                     `json:"total"`
+	Found        int                     `json:"found"`
+	UnsyncedOnly int                     `json:"unsynced_only"`
+	Instrumental int                     `json:"instrumental"`
+	Missing      int                     `json:"missing"`
+	Skipped      int                     `json:"skipped"`
+	Failed       int                     `json:"failed"`
+	Results      []LyricsBatchFileResult `json:"results"`
+}
+
+type LyricsBatchProgress struct {
+	Current int                   `json:"current"`
+	Total   int                   `json:"total"`
+	Result  LyricsBatchFileResult `json:"result"`
+}
+
+func lyricsTextIsSynced(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if lrcTimestampLinePattern.MatchString(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+func audioFileHasCompleteLyrics(audioPath string, mode string) bool {
+	if mode == lyricsModeEmbed || mode == lyricsModeBoth {
+		if lyrics, err := ExtractLyrics(audioPath); err == nil && (lyricsTextIsSynced(lyrics) || isInstrumentalLyricsText(lyrics)) {
+			return true
+		}
+	}
+
+	if mode == lyricsModeSidecar || mode == lyricsModeBoth {
+		base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+		for _, ext := range []string{".lrc", ".ttml"} {
+			data, err := os.ReadFile(base + ext)
+			if err != nil {
+				continue
+			}
+			if ext == ".ttml" || lyricsTextIsSynced(string(data)) || isInstrumentalLyricsText(string(data)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *LyricsBatchSummary) add(result LyricsBatchFileResult) {
+	switch result.Status {
+	case lyricsBatchStatusSynced:
+		s.Found++
+	case lyricsBatchStatusUnsynced:
+		s.UnsyncedOnly++
+	case lyricsBatchStatusInstrumental:
+		s.Instrumental++
+	case lyricsBatchStatusMissing:
+		s.Missing++
+	case lyricsBatchStatusSkipped:
+		s.Skipped++
+	case lyricsBatchStatusFailed:
+		s.Failed++
+	}
+	s.Results = append(s.Results, result)
+}
+
+func (c *LyricsClient) processLyricsBatchFile(audioPath string, mode string, overwrite bool) LyricsBatchFileResult {
+	result := LyricsBatchFileResult{File: audioPath}
+
+	if !overwrite && audioFileHasCompleteLyrics(audioPath, mode) {
+		result.Status = lyricsBatchStatusSkipped
+		return result
+	}
+
+	metadata, err := ExtractFullMetadataFromFile(audioPath)
+	if err != nil {
+		result.Status = lyricsBatchStatusFailed
+		result.Error = fmt.Sprintf("failed to read tags: %v", err)
+		return result
+	}
+
+	result.Title = strings.TrimSpace(metadata.Title)
+	result.Artist = strings.TrimSpace(metadata.Artist)
+	if result.Title == "" || result.Artist == "" {
+		result.Status = lyricsBatchStatusSkipped
+		result.Error = "file has no title or artist tag"
+		return result
+	}
+
+	duration := 0
+	if seconds, err := GetAudioDuration(audioPath); err == nil && seconds > 0 {
+		duration = int(seconds + 0.5)
+	}
+
+	best, err := c.FetchBestLyrics(LyricsQuery{
+		TrackName:  result.Title,
+		ArtistName: result.Artist,
+		AlbumName:  metadata.Album,
+		Duration:   duration,
+	})
+	if errors.Is(err, ErrLyricsNotFound) {
+		result.Status = lyricsBatchStatusMissing
+		return result
+	}
+	if err != nil {
+		result.Status = lyricsBatchStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Source = best.Source
+	if best.Instrumental && !hasLyrics(best.Lyrics) {
+		result.Status = lyricsBatchStatusInstrumental
+		if marker := InstrumentalLyrics(GetLyricsInstrumentalMarkerSetting()); marker != nil {
+			if err := c.saveLyricsWithMode(audioPath, marker, result.Title, result.Artist, mode); err != nil {
+				result.Status = lyricsBatchStatusFailed
+				result.Error = err.Error()
+			}
+		}
+		return result
+	}
+
+	if err := c.saveLyricsWithMode(audioPath, best.Lyrics, result.Title, result.Artist, mode); err != nil {
+		result.Status = lyricsBatchStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if isSynced(best.Lyrics) {
+		result.Status = lyricsBatchStatusSynced
+	} else {
+		result.Status = lyricsBatchStatusUnsynced
+	}
+	return result
+}
+
+func (c *LyricsClient) RunLyricsBatch(req LyricsBatchRequest, progress func(LyricsBatchProgress)) (*LyricsBatchSummary, error) {
+	folder := strings.TrimSpace(req.FolderPath)
+	if folder == "" {
+		return nil, fmt.Errorf("folder path is required")
+	}
+
+	files, err := ListAudioFiles(NormalizePath(folder))
+	if err != nil {
+		return nil, err
+	}
+
+	mode := GetLyricsModeSetting()
+	if strings.TrimSpace(req.Mode) != "" {
+		mode = normalizeLyricsMode(req.Mode)
+	}
+
+	summary := &LyricsBatchSummary{
+		Total:   len(files),
+		Results: make([]LyricsBatchFileResult, 0, len(files)),
+	}
+
+	for i, file := range files {
+		fmt.Printf("[LyricsBatch] (%d/%d) %s\n", i+1, len(files), file.Name)
+		result := c.processLyricsBatchFile(file.Path, mode, req.Overwrite)
+		summary.add(result)
+
+		if progress != nil {
+			progress(LyricsBatchProgress{Current: i + 1, Total: len(files), Result: result})
+		}
+	}
+
+	fmt.Printf("[LyricsBatch] Done: %d synced, %d unsynced only, %d instrumental, %d missing, %d skipped, %d failed\n",
+		summary.Found, summary.UnsyncedOnly, summary.Instrumental, summary.Missing, summary.Skipped, summary.Failed)
+	return summary, nil
+}
@@ -0,0 +1,313 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	lyricsConvertSynced = "synced"
+	lyricsConvertPlain  = "plain"
+)
+
+var lrcHeaderTagOrder = []string{"ti", "ar", "al", "au", "lr", "length", "by", "offset", "re", "ve"}
+
+type LyricsEditRequest struct {
+	FilePath  string  `json:"file_path"`
+	Lyrics    string  `json:"lyrics,omitempty"`
+	OffsetMs  int64   `json:"offset_ms,omitempty"`
+	Scale     float64 `json:"scale,omitempty"`
+	KeepTags  bool    `json:"keep_tags,omitempty"`
+	ConvertTo string  `json:"convert_to,omitempty"`
+	LineTimes []int64 `json:"line_times_ms,omitempty"`
+	Save      bool    `json:"save,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+}
+
+type LyricsEditResponse struct {
+	Success  bool   `json:"success"`
+	Lyrics   string `json:"lyrics"`
+	SyncType string `json:"sync_type"`
+	Saved    bool   `json:"saved,omitempty"`
+	File     string `json:"file,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func isAudioFileForLyrics(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac", ".mp3", ".m4a":
+		return true
+	default:
+		return false
+	}
+}
+
+func findAudioFileForLyricsSidecar(lyricsPath string) string {
+	base := strings.TrimSuffix(lyricsPath, filepath.Ext(lyricsPath))
+	for _, ext := range []string{".flac", ".mp3", ".m4a"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+func retimeLyricsValue(value string, scale float64, offsetMs int64) string {
+	if value == "" {
+		return ""
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return value
+	}
+
+	shifted := int64(math.Round(float64(ms)*scale)) + offsetMs
+	if shifted < 0 {
+		shifted = 0
+	}
+	return strconv.FormatInt(shifted, 10)
+}
+
+func retimeLyrics(lyrics *LyricsResponse, scale float64, offsetMs int64) *LyricsResponse {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	result := &LyricsResponse{
+		Error:    lyrics.Error,
+		SyncType: lyrics.SyncType,
+		Lines:    make([]LyricsLine, 0, len(lyrics.Lines)),
+	}
+	for _, line := range lyrics.Lines {
+		edited := LyricsLine{
+			StartTimeMs: retimeLyricsValue(line.StartTimeMs, scale, offsetMs),
+			Words:       line.Words,
+			EndTimeMs:   retimeLyricsValue(line.EndTimeMs, scale, offsetMs),
+		}
+		for _, syllable := range line.Syllables {
+			edited.Syllables = append(edited.Syllables, LyricsSyllable{
+				StartTimeMs: retimeLyricsValue(syllable.StartTimeMs, scale, offsetMs),
+				EndTimeMs:   retimeLyricsValue(syllable.EndTimeMs, scale, offsetMs),
+				Text:        syllable.Text,
+			})
+		}
+		result.Lines = append(result.Lines, edited)
+	}
+	return result
+}
+
+func ShiftLyrics(lyrics *LyricsResponse, offsetMs int64) *LyricsResponse {
+	return retimeLyrics(lyrics, 1, offsetMs)
+}
+
+func ScaleLyrics(lyrics *LyricsResponse, factor float64) *LyricsResponse {
+	return retimeLyrics(lyrics, factor, 0)
+}
+
+func LyricsToPlain(lyrics *LyricsResponse) *LyricsResponse {
+	result := &LyricsResponse{
+		Error:    lyrics.Error,
+		SyncType: lyricsSyncTypeUnsynced,
+		Lines:    make([]LyricsLine, 0, len(lyrics.Lines)),
+	}
+	for _, line := range lyrics.Lines {
+		result.Lines = append(result.Lines, LyricsLine{Words: line.Words})
+	}
+	return result
+}
+
+func LyricsToSynced(lyrics *LyricsResponse, lineTimesMs []int64) (*LyricsResponse, error) {
+	if isSynced(lyrics) {
+		return lyrics, nil
+	}
+
+	var lines []LyricsLine
+	for _, line := range lyrics.Lines {
+		if strings.TrimSpace(line.Words) != "" {
+			lines = append(lines, LyricsLine{Words: line.Words})
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no lyrics to time")
+	}
+
+	if len(lineTimesMs) != len(lines) {
+		return nil, fmt.Errorf("a timestamp is required for each of the %d lyric lines, got %d", len(lines), len(lineTimesMs))
+	}
+	for i := range lines {
+		if lineTimesMs[i] < 0 || (i > 0 && lineTimesMs[i] < lineTimesMs[i-1]) {
+			return nil, fmt.Errorf("line timestamps must be non-negative and in order")
+		}
+		lines[i].StartTimeMs = strconv.FormatInt(lineTimesMs[i], 10)
+	}
+
+	return &LyricsResponse{SyncType: lyricsSyncTypeLine, Lines: lines}, nil
+}
+
+func renderEditedLyrics(lyrics *LyricsResponse, tags map[string]string, keepTags bool) string {
+	var sb strings.Builder
+
+	if keepTags && len(tags) > 0 {
+		written := map[string]struct{}{}
+		for _, key := range lrcHeaderTagOrder {
+			if value, ok := tags[key]; ok {
+				sb.WriteString(fmt.Sprintf("[%s:%s]\n", key, value))
+				written[key] = struct{}{}
+			}
+		}
+
+		var rest []string
+		for key := range tags {
+			if _, ok := written[key]; !ok {
+				rest = append(rest, key)
+			}
+		}
+		sort.Strings(rest)
+		for _, key := range rest {
+			sb.WriteString(fmt.Sprintf("[%s:%s]\n", key, tags[key]))
+		}
+		sb.WriteString("\n")
+	}
+
+	writeEnhancedLRCLines(&sb, lyrics)
+	return sb.String()
+}
+
+func (c *LyricsClient) loadLyricsForEdit(req LyricsEditRequest) (*LyricsResponse, map[string]string, string, error) {
+	path := NormalizePath(strings.TrimSpace(req.FilePath))
+
+	audioPath := ""
+	if path != "" {
+		if isAudioFileForLyrics(path) {
+			audioPath = path
+		} else {
+			audioPath = findAudioFileForLyricsSidecar(path)
+		}
+	}
+
+	if strings.TrimSpace(req.Lyrics) != "" {
+		lyrics, tags := c.parseLRCContent(req.Lyrics)
+		return lyrics, tags, audioPath, nil
+	}
+
+	if path == "" {
+		return nil, nil, "", fmt.Errorf("file path is required")
+	}
+
+	if !isAudioFileForLyrics(path) {
+		lyrics, tags, err := c.ParseLyricsFile(path)
+		return lyrics, tags, audioPath, err
+	}
+
+	text, err := ExtractLyrics(path)
+	if err != nil {
+		return nil, nil, audioPath, fmt.Errorf("failed to read embedded lyrics: %w", err)
+	}
+	lyrics, tags := c.parseLRCContent(text)
+	if !hasLyrics(lyrics) {
+		return nil, tags, audioPath, fmt.Errorf("no embedded lyrics found in %s", filepath.Base(path))
+	}
+	return lyrics, tags, audioPath, nil
+}
+
+func (c *LyricsClient) saveEditedLyrics(req LyricsEditRequest, content string, audioPath string, lyrics *LyricsResponse, tags map[string]string) (string, error) {
+	path := NormalizePath(strings.TrimSpace(req.FilePath))
+
+	if audioPath == "" {
+		if path == "" || isAudioFileForLyrics(path) {
+			return "", fmt.Errorf("no file to save lyrics to")
+		}
+		if strings.EqualFold(filepath.Ext(path), ".ttml") {
+			content = c.ConvertToTTML(lyrics, tags["ti"], tags["ar"])
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("failed to write lyrics file: %w", err)
+		}
+		return path, nil
+	}
+
+	mode := lyricsModeEmbed
+	if !isAudioFileForLyrics(path) {
+		mode = lyricsModeSidecar
+	}
+	if strings.TrimSpace(req.Mode) != "" {
+		mode = normalizeLyricsMode(req.Mode)
+	}
+
+	var saved []string
+	if mode == lyricsModeEmbed || mode == lyricsModeBoth {
+		if err := EmbedLyricsOnlyUniversal(audioPath, content); err != nil {
+			return "", fmt.Errorf("failed to embed lyrics: %w", err)
+		}
+		saved = append(saved, audioPath)
+	}
+
+	if mode == lyricsModeSidecar || mode == lyricsModeBoth {
+		validated, err := validateLyricsDuration(content, audioPath)
+		if err != nil {
+			validated = content
+		}
+
+		sidecarPath := path
+		if isAudioFileForLyrics(path) {
+			sidecarPath = strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".lrc"
+		}
+		if strings.EqualFold(filepath.Ext(sidecarPath), ".ttml") {
+			validatedLyrics, _ := c.parseLRCContent(validated)
+			validated = c.ConvertToTTML(validatedLyrics, tags["ti"], tags["ar"])
+		}
+		if err := os.WriteFile(sidecarPath, []byte(validated), 0644); err != nil {
+			return "", fmt.Errorf("failed to write lyrics sidecar: %w", err)
+		}
+		saved = append(saved, sidecarPath)
+	}
+
+	return strings.Join(saved, ", "), nil
+}
+
+func (c *LyricsClient) EditLyrics(req LyricsEditRequest) (*LyricsEditResponse, error) {
+	lyrics, tags, audioPath, err := c.loadLyricsForEdit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(req.ConvertTo)) {
+	case lyricsConvertPlain:
+		lyrics = LyricsToPlain(lyrics)
+	case lyricsConvertSynced:
+		if lyrics, err = LyricsToSynced(lyrics, req.LineTimes); err != nil {
+			return nil, err
+		}
+	}
+
+	if isSynced(lyrics) && (req.OffsetMs != 0 || (req.Scale > 0 && req.Scale != 1)) {
+		lyrics = retimeLyrics(lyrics, req.Scale, req.OffsetMs)
+		delete(tags, "offset")
+	}
+
+	content := renderEditedLyrics(lyrics, tags, req.KeepTags)
+	resp := &LyricsEditResponse{
+		Success:  true,
+		Lyrics:   content,
+		SyncType: lyrics.SyncType,
+	}
+
+	if !req.Save {
+		return resp, nil
+	}
+
+	file, err := c.saveEditedLyrics(req, content, audioPath, lyrics, tags)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[EditLyrics] Saved lyrics to: %s\n", file)
+
+	resp.Saved = true
+	resp.File = file
+	return resp, nil
+}
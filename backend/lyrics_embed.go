@@ -0,0 +1,258 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	pathfilepath "path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/bogem/id3v2/v2"
+	"golang.org/x/text/language"
+)
+
+const (
+	lyricsModeEmbed   = "embed"
+	lyricsModeSidecar = "sidecar"
+	lyricsModeBoth    = "both"
+
+	syncedLyricsFrameID = "SYLT"
+)
+
+var latinLyricsStopwords = map[string][]string{
+	"eng": {"the", "and", "you", "that", "with", "for", "this", "love", "what", "don't"},
+	"spa": {"que", "los", "las", "por", "una", "con", "para", "como", "pero", "yo"},
+	"por": {"que", "não", "uma", "com", "você", "para", "mais", "meu", "eu", "tudo"},
+	"fra": {"les", "des", "une", "est", "pas", "pour", "dans", "qui", "je", "tu"},
+	"deu": {"und", "der", "die", "das", "nicht", "ich", "ist", "mit", "du", "ein"},
+	"ita": {"che", "non", "per", "una", "sono", "come", "della", "il", "ti", "mi"},
+}
+
+type syncedLyricsFrame struct {
+	Encoding id3v2.Encoding
+	Language string
+	Lines    []LyricsLine
+}
+
+func normalizeLyricsMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case lyricsModeSidecar:
+		return lyricsModeSidecar
+	case lyricsModeBoth:
+		return lyricsModeBoth
+	default:
+		return lyricsModeEmbed
+	}
+}
+
+func normalizeLyricsLanguageCode(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "auto") {
+		return ""
+	}
+
+	base, err := language.ParseBase(value)
+	if err != nil {
+		return ""
+	}
+	return base.ISO3()
+}
+
+func detectLyricsLanguage(text string) string {
+	scripts := map[string]int{}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hangul, r):
+			scripts["kor"]++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			scripts["jpn"] += 2
+		case unicode.Is(unicode.Han, r):
+			scripts["zho"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["rus"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ara"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["heb"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["tha"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["ell"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hin"]++
+		case unicode.Is(unicode.Latin, r):
+			scripts["latin"]++
+		}
+	}
+
+	best, bestCount := "latin", 0
+	for script, count := range scripts {
+		if count > bestCount {
+			best, bestCount = script, count
+		}
+	}
+	if bestCount == 0 {
+		return "XXX"
+	}
+	if best == "zho" && scripts["jpn"] > 0 {
+		best = "jpn"
+	}
+	if best != "latin" {
+		return best
+	}
+
+	words := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		words[word]++
+	}
+
+	bestLanguage, bestHits := "eng", 0
+	for _, code := range []string{"eng", "spa", "por", "fra", "deu", "ita"} {
+		hits := 0
+		for _, stopword := range latinLyricsStopwords[code] {
+			hits += words[stopword]
+		}
+		if hits > bestHits {
+			bestLanguage, bestHits = code, hits
+		}
+	}
+	return bestLanguage
+}
+
+func resolveLyricsLanguage(lyrics *LyricsResponse, tags map[string]string) string {
+	if code := normalizeLyricsLanguageCode(GetLyricsLanguageSetting()); code != "" {
+		return code
+	}
+	if code := normalizeLyricsLanguageCode(tags["la"]); code != "" {
+		return code
+	}
+	if !hasLyrics(lyrics) {
+		return "XXX"
+	}
+
+	var text strings.Builder
+	for _, line := range lyrics.Lines {
+		text.WriteString(line.Words)
+		text.WriteString("\n")
+	}
+	return detectLyricsLanguage(text.String())
+}
+
+func encodeSyncedLyricsText(text string, encoding id3v2.Encoding) []byte {
+	if !encoding.Equals(id3v2.EncodingUTF16) {
+		return append([]byte(text), encoding.TerminationBytes...)
+	}
+
+	buf := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		buf = binary.LittleEndian.AppendUint16(buf, unit)
+	}
+	return append(buf, encoding.TerminationBytes...)
+}
+
+func (f syncedLyricsFrame) body() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(f.Encoding.Key)
+	buf.WriteString(f.Language)
+	buf.WriteByte(2)
+	buf.WriteByte(1)
+	buf.Write(encodeSyncedLyricsText("", f.Encoding))
+
+	for _, line := range f.Lines {
+		ms, err := strconv.ParseInt(line.StartTimeMs, 10, 64)
+		if err != nil || ms < 0 {
+			continue
+		}
+		buf.Write(encodeSyncedLyricsText(line.Words, f.Encoding))
+		_ = binary.Write(&buf, binary.BigEndian, uint32(ms))
+	}
+
+	return buf.Bytes()
+}
+
+func (f syncedLyricsFrame) Size() int {
+	return len(f.body())
+}
+
+func (f syncedLyricsFrame) UniqueIdentifier() string {
+	return f.Language
+}
+
+func (f syncedLyricsFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(f.body())
+	return int64(n), err
+}
+
+func addSyncedLyricsFrame(tag *id3v2.Tag, lyrics *LyricsResponse, languageCode string) bool {
+	if !isSynced(lyrics) {
+		return false
+	}
+
+	encoding := id3v2.EncodingUTF8
+	if tag.Version() < 4 {
+		encoding = id3v2.EncodingUTF16
+	}
+
+	frame := syncedLyricsFrame{Encoding: encoding, Language: languageCode}
+	for _, line := range lyrics.Lines {
+		if line.StartTimeMs != "" {
+			frame.Lines = append(frame.Lines, line)
+		}
+	}
+	if len(frame.Lines) == 0 {
+		return false
+	}
+
+	tag.AddFrame(syncedLyricsFrameID, frame)
+	return true
+}
+
+func (c *LyricsClient) WriteLyricsSidecar(audioPath string, lyrics *LyricsResponse, trackName, artistName string) (string, error) {
+	format := GetLyricsFormatSetting()
+	sidecarPath := strings.TrimSuffix(audioPath, pathfilepath.Ext(audioPath)) + lyricsFormatExtension(format)
+	content := c.FormatLyrics(lyrics, trackName, artistName, format)
+	if err := os.WriteFile(sidecarPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write lyrics sidecar: %w", err)
+	}
+	return sidecarPath, nil
+}
+
+func (c *LyricsClient) SaveLyricsForAudioFile(audioPath string, lyrics *LyricsResponse, trackName, artistName string) error {
+	return c.saveLyricsWithMode(audioPath, lyrics, trackName, artistName, GetLyricsModeSetting())
+}
+
+func (c *LyricsClient) saveLyricsWithMode(audioPath string, lyrics *LyricsResponse, trackName, artistName, mode string) error {
+	if !hasLyrics(lyrics) {
+		return fmt.Errorf("no lyrics to save")
+	}
+
+	var errs []string
+
+	if mode == lyricsModeEmbed || mode == lyricsModeBoth {
+		if err := EmbedLyricsOnlyUniversal(audioPath, c.FormatEmbeddedLyrics(lyrics, trackName, artistName)); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			fmt.Printf("Lyrics embedded into: %s\n", audioPath)
+		}
+	}
+
+	if mode == lyricsModeSidecar || mode == lyricsModeBoth {
+		if sidecarPath, err := c.WriteLyricsSidecar(audioPath, lyrics, trackName, artistName); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			fmt.Printf("Lyrics saved to: %s\n", sidecarPath)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
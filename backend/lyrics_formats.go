@@ -0,0 +1,430 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	lyricsSyncTypeLine     = "LINE_SYNCED"
+	lyricsSyncTypeWord     = "WORD_SYNCED"
+	lyricsSyncTypeUnsynced = "UNSYNCED"
+
+	lyricsFormatLRC         = "lrc"
+	lyricsFormatEnhancedLRC = "elrc"
+	lyricsFormatTTML        = "ttml"
+)
+
+var enhancedLRCWordPattern = regexp.MustCompile(`<(\d+:\d+(?:[.:]\d+)?)>`)
+
+type LyricsSyllable struct {
+	StartTimeMs string `json:"startTimeMs"`
+	EndTimeMs   string `json:"endTimeMs,omitempty"`
+	Text        string `json:"text"`
+}
+
+func normalizeLyricsFormat(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case lyricsFormatEnhancedLRC, "enhanced", "a2":
+		return lyricsFormatEnhancedLRC
+	case lyricsFormatTTML:
+		return lyricsFormatTTML
+	default:
+		return lyricsFormatLRC
+	}
+}
+
+func lyricsFormatExtension(format string) string {
+	if format == lyricsFormatTTML {
+		return ".ttml"
+	}
+	return ".lrc"
+}
+
+func hasWordTiming(lyrics *LyricsResponse) bool {
+	if lyrics == nil {
+		return false
+	}
+	for _, line := range lyrics.Lines {
+		if len(line.Syllables) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEnhancedLRCWords(text string, lineStartMs int64) (string, []LyricsSyllable) {
+	matches := enhancedLRCWordPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var syllables []LyricsSyllable
+	if prefix := text[:matches[0][0]]; strings.TrimSpace(prefix) != "" {
+		syllables = append(syllables, LyricsSyllable{
+			StartTimeMs: strconv.FormatInt(lineStartMs, 10),
+			Text:        prefix,
+		})
+	}
+	for i, match := range matches {
+		start := lrcTimestampToMs(text[match[2]:match[3]])
+		segmentEnd := len(text)
+		if i+1 < len(matches) {
+			segmentEnd = matches[i+1][0]
+		}
+
+		if len(syllables) > 0 && syllables[len(syllables)-1].EndTimeMs == "" {
+			syllables[len(syllables)-1].EndTimeMs = strconv.FormatInt(start, 10)
+		}
+
+		segment := text[match[1]:segmentEnd]
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		syllables = append(syllables, LyricsSyllable{
+			StartTimeMs: strconv.FormatInt(start, 10),
+			Text:        segment,
+		})
+	}
+
+	var plain strings.Builder
+	for _, syllable := range syllables {
+		plain.WriteString(syllable.Text)
+	}
+
+	return strings.Join(strings.Fields(plain.String()), " "), syllables
+}
+
+func msToEnhancedLRCTimestamp(msStr string) string {
+	return "<" + strings.Trim(msToLRCTimestamp(msStr), "[]") + ">"
+}
+
+func (c *LyricsClient) ConvertToEnhancedLRC(lyrics *LyricsResponse, trackName, artistName string) string {
+	if !hasWordTiming(lyrics) {
+		return c.ConvertToLRC(lyrics, trackName, artistName)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("[ti:%s]\n", trackName))
+	sb.WriteString(fmt.Sprintf("[ar:%s]\n", artistName))
+	sb.WriteString("[by:SpotiFlac]\n")
+	sb.WriteString("\n")
+	writeEnhancedLRCLines(&sb, lyrics)
+
+	return sb.String()
+}
+
+func writeEnhancedLRCLines(sb *strings.Builder, lyrics *LyricsResponse) {
+	for _, line := range lyrics.Lines {
+		if line.Words == "" {
+			continue
+		}
+
+		if line.StartTimeMs == "" {
+			sb.WriteString(fmt.Sprintf("%s\n", line.Words))
+			continue
+		}
+
+		sb.WriteString(msToLRCTimestamp(line.StartTimeMs))
+		if len(line.Syllables) == 0 {
+			sb.WriteString(fmt.Sprintf("%s\n", line.Words))
+			continue
+		}
+
+		for _, syllable := range line.Syllables {
+			sb.WriteString(msToEnhancedLRCTimestamp(syllable.StartTimeMs))
+			sb.WriteString(syllable.Text)
+		}
+		if last := line.Syllables[len(line.Syllables)-1]; last.EndTimeMs != "" {
+			sb.WriteString(msToEnhancedLRCTimestamp(last.EndTimeMs))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+func parseTTMLTime(value string) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	switch {
+	case strings.HasSuffix(value, "ms"):
+		ms, _ := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64)
+		return int64(ms)
+	case strings.HasSuffix(value, "s"):
+		seconds, _ := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64)
+		return int64(seconds * 1000)
+	}
+
+	var total float64
+	for _, part := range strings.Split(value, ":") {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + number
+	}
+	return int64(total*1000 + 0.5)
+}
+
+func formatTTMLTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+func ParseTTMLLyrics(data []byte) (*LyricsResponse, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	resp := &LyricsResponse{
+		SyncType: lyricsSyncTypeLine,
+		Lines:    []LyricsLine{},
+	}
+
+	var line *LyricsLine
+	var text strings.Builder
+	var spans []bool
+	syllableDepth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TTML: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string)
+			for _, attr := range element.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+
+			switch element.Name.Local {
+			case "p":
+				line = &LyricsLine{}
+				text.Reset()
+				if begin, ok := attrs["begin"]; ok {
+					line.StartTimeMs = strconv.FormatInt(parseTTMLTime(begin), 10)
+				}
+				if end, ok := attrs["end"]; ok {
+					line.EndTimeMs = strconv.FormatInt(parseTTMLTime(end), 10)
+				}
+			case "span":
+				begin, ok := attrs["begin"]
+				timed := line != nil && ok
+				spans = append(spans, timed)
+				if !timed {
+					continue
+				}
+				syllable := LyricsSyllable{StartTimeMs: strconv.FormatInt(parseTTMLTime(begin), 10)}
+				if end, ok := attrs["end"]; ok {
+					syllable.EndTimeMs = strconv.FormatInt(parseTTMLTime(end), 10)
+				}
+				line.Syllables = append(line.Syllables, syllable)
+				syllableDepth++
+			case "br":
+				text.WriteString(" ")
+			}
+		case xml.CharData:
+			if line == nil {
+				continue
+			}
+			value := string(element)
+			text.WriteString(value)
+			if len(line.Syllables) == 0 {
+				continue
+			}
+			last := &line.Syllables[len(line.Syllables)-1]
+			if strings.TrimSpace(value) == "" {
+				if value != "" && last.Text != "" && !strings.HasSuffix(last.Text, " ") {
+					last.Text += " "
+				}
+			} else if syllableDepth > 0 {
+				last.Text += strings.TrimSpace(value)
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "span":
+				if len(spans) > 0 {
+					if spans[len(spans)-1] {
+						syllableDepth--
+					}
+					spans = spans[:len(spans)-1]
+				}
+			case "p":
+				if line != nil {
+					var syllables []LyricsSyllable
+					for _, syllable := range line.Syllables {
+						if strings.TrimSpace(syllable.Text) != "" {
+							syllables = append(syllables, syllable)
+						}
+					}
+					line.Syllables = syllables
+					line.Words = strings.Join(strings.Fields(text.String()), " ")
+					if line.Words != "" {
+						if len(line.Syllables) > 0 {
+							resp.SyncType = lyricsSyncTypeWord
+						}
+						resp.Lines = append(resp.Lines, *line)
+					}
+				}
+				line = nil
+			}
+		}
+	}
+
+	if len(resp.Lines) == 0 {
+		return nil, fmt.Errorf("no lyrics found in TTML")
+	}
+
+	for _, line := range resp.Lines {
+		if line.StartTimeMs == "" {
+			resp.SyncType = lyricsSyncTypeUnsynced
+			break
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *LyricsClient) ConvertToTTML(lyrics *LyricsResponse, trackName, artistName string) string {
+	var sb strings.Builder
+
+	timing := "Line"
+	if hasWordTiming(lyrics) {
+		timing = "Word"
+	}
+
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(fmt.Sprintf(`<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:itunes="http://music.apple.com/lyric-ttml-internal" itunes:timing="%s">`+"\n", timing))
+	sb.WriteString("  <head>\n    <metadata>\n")
+	sb.WriteString(fmt.Sprintf("      <ttm:title>%s</ttm:title>\n", escapeXMLText(trackName)))
+	sb.WriteString(fmt.Sprintf("      <ttm:agent type=\"person\"><ttm:name>%s</ttm:name></ttm:agent>\n", escapeXMLText(artistName)))
+	sb.WriteString("    </metadata>\n  </head>\n  <body>\n    <div>\n")
+
+	lines := make([]LyricsLine, 0, len(lyrics.Lines))
+	for _, line := range lyrics.Lines {
+		if line.Words != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	for i, line := range lines {
+		if line.StartTimeMs == "" {
+			sb.WriteString(fmt.Sprintf("      <p>%s</p>\n", escapeXMLText(line.Words)))
+			continue
+		}
+
+		start, _ := strconv.ParseInt(line.StartTimeMs, 10, 64)
+		end, _ := strconv.ParseInt(line.EndTimeMs, 10, 64)
+		if end <= start && len(line.Syllables) > 0 {
+			end, _ = strconv.ParseInt(line.Syllables[len(line.Syllables)-1].EndTimeMs, 10, 64)
+		}
+		if end <= start && i+1 < len(lines) {
+			end, _ = strconv.ParseInt(lines[i+1].StartTimeMs, 10, 64)
+		}
+		if end <= start {
+			end = start + 5000
+		}
+
+		sb.WriteString(fmt.Sprintf(`      <p begin="%s" end="%s">`, formatTTMLTime(start), formatTTMLTime(end)))
+		if len(line.Syllables) == 0 {
+			sb.WriteString(escapeXMLText(line.Words))
+		} else {
+			for j, syllable := range line.Syllables {
+				syllableStart, _ := strconv.ParseInt(syllable.StartTimeMs, 10, 64)
+				syllableEnd, _ := strconv.ParseInt(syllable.EndTimeMs, 10, 64)
+				if syllableEnd <= syllableStart {
+					syllableEnd = end
+					if j+1 < len(line.Syllables) {
+						syllableEnd, _ = strconv.ParseInt(line.Syllables[j+1].StartTimeMs, 10, 64)
+					}
+				}
+				sb.WriteString(fmt.Sprintf(`<span begin="%s" end="%s">%s</span>`, formatTTMLTime(syllableStart), formatTTMLTime(syllableEnd), escapeXMLText(strings.TrimSpace(syllable.Text))))
+				if strings.HasSuffix(syllable.Text, " ") && j+1 < len(line.Syllables) {
+					sb.WriteString(" ")
+				}
+			}
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	sb.WriteString("    </div>\n  </body>\n</tt>\n")
+	return sb.String()
+}
+
+func escapeXMLText(value string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
+
+func (c *LyricsClient) FormatLyrics(lyrics *LyricsResponse, trackName, artistName, format string) string {
+	switch normalizeLyricsFormat(format) {
+	case lyricsFormatEnhancedLRC:
+		return c.ConvertToEnhancedLRC(lyrics, trackName, artistName)
+	case lyricsFormatTTML:
+		return c.ConvertToTTML(lyrics, trackName, artistName)
+	default:
+		return c.ConvertToLRC(lyrics, trackName, artistName)
+	}
+}
+
+func (c *LyricsClient) FormatEmbeddedLyrics(lyrics *LyricsResponse, trackName, artistName string) string {
+	if GetLyricsFormatSetting() == lyricsFormatEnhancedLRC {
+		return c.ConvertToEnhancedLRC(lyrics, trackName, artistName)
+	}
+	return c.ConvertToLRC(lyrics, trackName, artistName)
+}
+
+func (c *LyricsClient) ParseLyricsFile(path string) (*LyricsResponse, map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read lyrics file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttml", ".xml":
+		lyrics, err := ParseTTMLLyrics(data)
+		return lyrics, map[string]string{}, err
+	default:
+		lyrics, tags := c.parseLRCContent(string(data))
+		if !hasLyrics(lyrics) {
+			return nil, tags, fmt.Errorf("no lyrics found in %s", filepath.Base(path))
+		}
+		return lyrics, tags, nil
+	}
+}
+
+func (c *LyricsClient) ConvertLyricsFile(inputPath, format string) (string, error) {
+	lyrics, tags, err := c.ParseLyricsFile(inputPath)
+	if err != nil {
+		return "", err
+	}
+
+	format = normalizeLyricsFormat(format)
+	base := strings.TrimSuffix(inputPath, filepath.Ext(inputPath))
+	outputPath := base + lyricsFormatExtension(format)
+	if outputPath == inputPath {
+		outputPath = base + "." + format + lyricsFormatExtension(format)
+	}
+
+	content := c.FormatLyrics(lyrics, tags["ti"], tags["ar"], format)
+	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write lyrics file: %w", err)
+	}
+
+	return outputPath, nil
+}
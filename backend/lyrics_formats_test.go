@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnhancedLRCWords(t *testing.T) {
+	words, syllables := parseEnhancedLRCWords("Hello <00:01.50>big <00:02.00>world<00:02.50>", 1000)
+	if words != "Hello big world" {
+		t.Errorf("words = %q, want %q", words, "Hello big world")
+	}
+	want := []LyricsSyllable{
+		{StartTimeMs: "1000", EndTimeMs: "1500", Text: "Hello "},
+		{StartTimeMs: "1500", EndTimeMs: "2000", Text: "big "},
+		{StartTimeMs: "2000", EndTimeMs: "2500", Text: "world"},
+	}
+	if !reflect.DeepEqual(syllables, want) {
+		t.Errorf("syllables = %+v, want %+v", syllables, want)
+	}
+
+	if words, syllables := parseEnhancedLRCWords("Plain line", 1000); words != "Plain line" || syllables != nil {
+		t.Errorf("plain line = %q %+v", words, syllables)
+	}
+}
+
+func TestEnhancedLRCRoundTrip(t *testing.T) {
+	client := NewLyricsClient()
+	input := "[ti:Song]\n[ar:Artist]\n\n" +
+		"[00:01.00]Hello <00:01.50>big <00:02.00>world<00:02.50>\n" +
+		"[00:03.00]<00:03.00>Second <00:03.40>line<00:04.00>\n"
+
+	first, _ := client.parseLRCContent(input)
+	if first.SyncType != lyricsSyncTypeWord {
+		t.Fatalf("sync type = %q, want %q", first.SyncType, lyricsSyncTypeWord)
+	}
+	if len(first.Lines) != 2 || first.Lines[0].Words != "Hello big world" || len(first.Lines[0].Syllables) != 3 {
+		t.Fatalf("unexpected first parse: %+v", first.Lines)
+	}
+
+	second, _ := client.parseLRCContent(client.ConvertToEnhancedLRC(first, "Song", "Artist"))
+	if !reflect.DeepEqual(first.Lines, second.Lines) {
+		t.Errorf("round trip changed lines:\n got %+v\nwant %+v", second.Lines, first.Lines)
+	}
+}
+
+func TestParseTTMLLyricsNestedSpans(t *testing.T) {
+	input := `<tt xmlns="http://www.w3.org/ns/ttml"><body><div>
+<p begin="00:00:01.000" end="00:00:03.000"><span begin="00:00:01.000" end="00:00:01.500">Hello</span> <span ttm:role="x-bg"><span begin="00:00:01.500" end="00:00:02.000">(oh</span> <span begin="00:00:02.000" end="00:00:02.500">yeah)</span></span> <span begin="00:00:02.500" end="00:00:03.000">world</span></p>
+</div></body></tt>`
+
+	lyrics, err := ParseTTMLLyrics([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseTTMLLyrics: %v", err)
+	}
+	if len(lyrics.Lines) != 1 {
+		t.Fatalf("lines = %d, want 1", len(lyrics.Lines))
+	}
+	line := lyrics.Lines[0]
+	if line.Words != "Hello (oh yeah) world" {
+		t.Errorf("words = %q", line.Words)
+	}
+	var texts []string
+	for _, syllable := range line.Syllables {
+		texts = append(texts, syllable.Text)
+	}
+	if want := []string{"Hello ", "(oh ", "yeah) ", "world"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("syllables = %q, want %q", texts, want)
+	}
+}
+
+func TestTTMLRoundTrip(t *testing.T) {
+	client := NewLyricsClient()
+	first, _ := client.parseLRCContent("[00:01.00]Hello <00:01.50>big <00:02.00>world<00:02.50>\n[00:03.00]Line only\n")
+
+	second, err := ParseTTMLLyrics([]byte(client.ConvertToTTML(first, "Song & Co", "Artist")))
+	if err != nil {
+		t.Fatalf("ParseTTMLLyrics: %v", err)
+	}
+	if second.SyncType != lyricsSyncTypeWord {
+		t.Errorf("sync type = %q, want %q", second.SyncType, lyricsSyncTypeWord)
+	}
+	if len(second.Lines) != len(first.Lines) {
+		t.Fatalf("lines = %d, want %d", len(second.Lines), len(first.Lines))
+	}
+	for i := range first.Lines {
+		if first.Lines[i].Words != second.Lines[i].Words || first.Lines[i].StartTimeMs != second.Lines[i].StartTimeMs {
+			t.Errorf("line %d = %+v, want %+v", i, second.Lines[i], first.Lines[i])
+		}
+	}
+	if !reflect.DeepEqual(first.Lines[0].Syllables, second.Lines[0].Syllables) {
+		t.Errorf("syllables = %+v, want %+v", second.Lines[0].Syllables, first.Lines[0].Syllables)
+	}
+}
@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+)
+
+const defaultInstrumentalLyricsMarker = "[Instrumental]"
+
+var ErrLyricsInstrumental = errors.New("track is instrumental")
+
+func isInstrumentalLyricsCandidate(query LyricsQuery, candidate LyricsCandidate) bool {
+	if !candidate.Instrumental {
+		return false
+	}
+	if delta := lyricsCandidateDurationDelta(query, candidate); delta > 5 {
+		return false
+	}
+	if candidate.TrackName != "" && normalizeQobuzSearchValue(simplifyTrackName(candidate.TrackName)) != normalizeQobuzSearchValue(simplifyTrackName(query.TrackName)) {
+		return false
+	}
+	return true
+}
+
+func InstrumentalLyrics(marker string) *LyricsResponse {
+	if strings.TrimSpace(marker) == "" {
+		return nil
+	}
+	return &LyricsResponse{
+		SyncType: lyricsSyncTypeUnsynced,
+		Lines:    []LyricsLine{{Words: strings.TrimSpace(marker)}},
+	}
+}
+
+func isInstrumentalLyricsText(text string) bool {
+	marker := strings.TrimSpace(GetLyricsInstrumentalMarkerSetting())
+	if marker == "" {
+		marker = defaultInstrumentalLyricsMarker
+	}
+
+	found := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || lrcMetadataTagPattern.MatchString(line) {
+			continue
+		}
+		if !strings.EqualFold(line, marker) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
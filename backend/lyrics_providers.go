@@ -0,0 +1,463 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	lyricsProviderLRCLib = "lrclib"
+	lyricsProviderLocal  = "local"
+
+	defaultLyricsProviders       = "local-lrclib"
+	defaultLyricsProviderTimeout = 15 * time.Second
+	maxLyricsSearchCandidates    = 5
+	localLyricsIndexTTL          = 2 * time.Minute
+)
+
+var (
+	lrcTimestampLinePattern = regexp.MustCompile(`^\[\d+:\d+(?:[.:]\d+)?\]`)
+	lrcMetadataTagPattern   = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	lrcTrackPrefixPattern   = regexp.MustCompile(`^\d{2,3}(?:\.\s*|\s+-\s+)`)
+
+	localLyricsIndexMu    sync.Mutex
+	localLyricsIndexCache = map[string]*localLyricsIndex{}
+)
+
+type localLyricsIndex struct {
+	builtAt time.Time
+	files   map[string][]string
+}
+
+type LyricsQuery struct {
+	SpotifyID  string
+	TrackName  string
+	ArtistName string
+	AlbumName  string
+	Duration   int
+}
+
+type LyricsCandidate struct {
+	Lyrics       *LyricsResponse
+	Source       string
+	TrackName    string
+	ArtistName   string
+	Duration     int
+	Instrumental bool
+}
+
+type LyricsProvider interface {
+	Name() string
+	Fetch(ctx context.Context, query LyricsQuery) ([]LyricsCandidate, error)
+}
+
+type LyricsProviderFactory func(client *LyricsClient) LyricsProvider
+
+var (
+	lyricsProviderRegistryMu sync.RWMutex
+	lyricsProviderRegistry   = map[string]LyricsProviderFactory{}
+)
+
+func RegisterLyricsProvider(name string, factory LyricsProviderFactory) {
+	lyricsProviderRegistryMu.Lock()
+	defer lyricsProviderRegistryMu.Unlock()
+	lyricsProviderRegistry[strings.ToLower(strings.TrimSpace(name))] = factory
+}
+
+func init() {
+	RegisterLyricsProvider(lyricsProviderLRCLib, func(client *LyricsClient) LyricsProvider {
+		return &lrcLibLyricsProvider{client: client}
+	})
+	RegisterLyricsProvider(lyricsProviderLocal, func(client *LyricsClient) LyricsProvider {
+		return &localLyricsProvider{client: client, dir: GetLyricsLocalDirSetting()}
+	})
+}
+
+func sanitizeLyricsProvidersValue(value interface{}) []string {
+	rawProviders, _ := value.(string)
+
+	lyricsProviderRegistryMu.RLock()
+	defer lyricsProviderRegistryMu.RUnlock()
+
+	seen := make(map[string]struct{})
+	providers := make([]string, 0, len(lyricsProviderRegistry))
+	for _, rawPart := range strings.Split(strings.TrimSpace(strings.ToLower(rawProviders)), "-") {
+		part := strings.TrimSpace(rawPart)
+		if _, ok := lyricsProviderRegistry[part]; !ok {
+			continue
+		}
+		if _, ok := seen[part]; ok {
+			continue
+		}
+		seen[part] = struct{}{}
+		providers = append(providers, part)
+	}
+
+	if len(providers) == 0 {
+		return strings.Split(defaultLyricsProviders, "-")
+	}
+
+	return providers
+}
+
+func lyricsCandidateDurationDelta(query LyricsQuery, candidate LyricsCandidate) int {
+	if query.Duration <= 0 || candidate.Duration <= 0 {
+		return -1
+	}
+	delta := query.Duration - candidate.Duration
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta
+}
+
+func scoreLyricsCandidate(query LyricsQuery, candidate LyricsCandidate) int {
+	if !hasLyrics(candidate.Lyrics) {
+		return -1
+	}
+
+	score := 0
+	if isSynced(candidate.Lyrics) {
+		score += 100
+	}
+
+	switch delta := lyricsCandidateDurationDelta(query, candidate); {
+	case delta < 0:
+	case delta <= 2:
+		score += 60
+	case delta <= 5:
+		score += 30
+	case delta > 10:
+		score -= 80
+	}
+
+	if candidate.TrackName != "" {
+		if normalizeQobuzSearchValue(simplifyTrackName(candidate.TrackName)) == normalizeQobuzSearchValue(simplifyTrackName(query.TrackName)) {
+			score += 20
+		}
+	}
+	if candidate.ArtistName != "" {
+		if normalizeQobuzSearchValue(candidate.ArtistName) == normalizeQobuzSearchValue(query.ArtistName) {
+			score += 10
+		}
+	}
+
+	return score
+}
+
+func isConfidentLyricsCandidate(query LyricsQuery, candidate LyricsCandidate) bool {
+	if !isSynced(candidate.Lyrics) {
+		return false
+	}
+	delta := lyricsCandidateDurationDelta(query, candidate)
+	return delta >= 0 && delta <= 2
+}
+
+var ErrLyricsNotFound = errors.New("lyrics not found in any source")
+
+func (c *LyricsClient) FetchBestLyrics(query LyricsQuery) (*LyricsCandidate, error) {
+	var best, instrumental *LyricsCandidate
+	var providerErrs []error
+	bestScore := -1
+
+	for _, name := range GetLyricsProvidersSetting() {
+		lyricsProviderRegistryMu.RLock()
+		factory, ok := lyricsProviderRegistry[name]
+		lyricsProviderRegistryMu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		provider := factory(c)
+		ctx, cancel := context.WithTimeout(context.Background(), GetLyricsProviderTimeoutSetting(name))
+		candidates, err := provider.Fetch(ctx, query)
+		cancel()
+		if err != nil {
+			fmt.Printf("   [%s] %v\n", provider.Name(), err)
+			providerErrs = append(providerErrs, fmt.Errorf("%s: %w", provider.Name(), err))
+		}
+
+		confident := false
+		for i := range candidates {
+			if instrumental == nil && isInstrumentalLyricsCandidate(query, candidates[i]) {
+				instrumental = &candidates[i]
+			}
+			score := scoreLyricsCandidate(query, candidates[i])
+			if score < 0 {
+				continue
+			}
+			if score > bestScore {
+				best = &candidates[i]
+				bestScore = score
+			}
+			if isConfidentLyricsCandidate(query, candidates[i]) {
+				confident = true
+			}
+		}
+
+		if best == nil {
+			fmt.Printf("   [%s] no lyrics\n", provider.Name())
+			continue
+		}
+		if confident {
+			fmt.Printf("   [%s] Synced found via %s\n", provider.Name(), best.Source)
+			return best, nil
+		}
+	}
+
+	if best == nil && instrumental != nil {
+		fmt.Printf("   Marked as instrumental by: %s\n", instrumental.Source)
+		return instrumental, nil
+	}
+	if best == nil {
+		if len(providerErrs) > 0 {
+			return nil, fmt.Errorf("lyrics lookup failed: %w", errors.Join(providerErrs...))
+		}
+		return nil, ErrLyricsNotFound
+	}
+
+	if isSynced(best.Lyrics) {
+		fmt.Printf("   Best synced match from: %s\n", best.Source)
+	} else {
+		fmt.Printf("   No synced found, using unsynced from: %s\n", best.Source)
+	}
+	return best, nil
+}
+
+type lrcLibLyricsProvider struct {
+	client *LyricsClient
+}
+
+func (p *lrcLibLyricsProvider) Name() string {
+	return "LRCLIB"
+}
+
+func (p *lrcLibLyricsProvider) candidate(resp *LRCLibResponse, source string) LyricsCandidate {
+	return LyricsCandidate{
+		Lyrics:       p.client.convertLRCLibToLyricsResponse(resp),
+		Source:       source,
+		TrackName:    resp.TrackName,
+		ArtistName:   resp.ArtistName,
+		Duration:     int(resp.Duration + 0.5),
+		Instrumental: resp.Instrumental,
+	}
+}
+
+func (p *lrcLibLyricsProvider) Fetch(ctx context.Context, query LyricsQuery) ([]LyricsCandidate, error) {
+	var candidates []LyricsCandidate
+	var lastErr error
+
+	done := func() bool {
+		for _, candidate := range candidates {
+			if isConfidentLyricsCandidate(query, candidate) {
+				return true
+			}
+		}
+		return ctx.Err() != nil
+	}
+
+	get := func(trackName, albumName, source string) {
+		resp, err := p.client.fetchLRCLibGet(ctx, trackName, query.ArtistName, albumName, query.Duration)
+		if err != nil {
+			lastErr = err
+			return
+		}
+		candidates = append(candidates, p.candidate(resp, source))
+	}
+
+	search := func(trackName, source string) {
+		results, err := p.client.fetchLRCLibSearch(ctx, trackName, query.ArtistName)
+		if err != nil {
+			lastErr = err
+			return
+		}
+		added := 0
+		for i := range results {
+			if results[i].SyncedLyrics == "" && results[i].PlainLyrics == "" && !results[i].Instrumental {
+				continue
+			}
+			candidates = append(candidates, p.candidate(&results[i], source))
+			added++
+			if added >= maxLyricsSearchCandidates {
+				break
+			}
+		}
+	}
+
+	get(query.TrackName, query.AlbumName, "LRCLIB")
+	if !done() && query.AlbumName != "" {
+		get(query.TrackName, "", "LRCLIB (no album)")
+	}
+	if !done() {
+		search(query.TrackName, "LRCLIB Search")
+	}
+
+	simplifiedTrack := simplifyTrackName(query.TrackName)
+	if simplifiedTrack != query.TrackName {
+		if !done() {
+			get(simplifiedTrack, query.AlbumName, "LRCLIB (simplified)")
+		}
+		if !done() {
+			search(simplifiedTrack, "LRCLIB Search (simplified)")
+		}
+	}
+
+	if len(candidates) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return candidates, nil
+}
+
+type localLyricsProvider struct {
+	client *LyricsClient
+	dir    string
+}
+
+func (p *localLyricsProvider) Name() string {
+	return "Local"
+}
+
+func normalizeLocalLyricsName(value string) string {
+	return normalizeQobuzSearchValue(SanitizeFilename(value))
+}
+
+func buildLocalLyricsIndex(ctx context.Context, dir string) (*localLyricsIndex, error) {
+	index := &localLyricsIndex{builtAt: time.Now(), files: map[string][]string{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".lrc" && ext != ".ttml") {
+			return nil
+		}
+
+		base := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		keys := map[string]struct{}{normalizeLocalLyricsName(base): {}}
+		keys[normalizeLocalLyricsName(lrcTrackPrefixPattern.ReplaceAllString(base, ""))] = struct{}{}
+		for key := range keys {
+			index.files[key] = append(index.files[key], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func getLocalLyricsIndex(ctx context.Context, dir string) (*localLyricsIndex, error) {
+	localLyricsIndexMu.Lock()
+	defer localLyricsIndexMu.Unlock()
+
+	if index, ok := localLyricsIndexCache[dir]; ok && time.Since(index.builtAt) < localLyricsIndexTTL {
+		return index, nil
+	}
+
+	index, err := buildLocalLyricsIndex(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	localLyricsIndexCache[dir] = index
+	return index, nil
+}
+
+func (p *localLyricsProvider) Fetch(ctx context.Context, query LyricsQuery) ([]LyricsCandidate, error) {
+	if strings.TrimSpace(p.dir) == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(p.dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("lyrics directory not found: %s", p.dir)
+	}
+
+	title := ResolveFilenameTitle(query.TrackName)
+	wanted := map[string]struct{}{}
+	for _, name := range []string{
+		title + " - " + query.ArtistName,
+		query.ArtistName + " - " + title,
+		simplifyTrackName(query.TrackName) + " - " + query.ArtistName,
+		query.ArtistName + " - " + simplifyTrackName(query.TrackName),
+		query.SpotifyID,
+	} {
+		if key := normalizeLocalLyricsName(name); key != "" && key != "unknown" {
+			wanted[key] = struct{}{}
+		}
+	}
+
+	index, err := getLocalLyricsIndex(ctx, filepath.Clean(p.dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	seen := map[string]struct{}{}
+	for key := range wanted {
+		for _, path := range index.files[key] {
+			if _, ok := seen[path]; !ok {
+				seen[path] = struct{}{}
+				matches = append(matches, path)
+			}
+		}
+	}
+	sort.Strings(matches)
+
+	candidates := make([]LyricsCandidate, 0, len(matches))
+	for _, path := range matches {
+		lyrics, tags, err := p.client.ParseLyricsFile(path)
+		if err != nil {
+			continue
+		}
+
+		candidate := LyricsCandidate{
+			Lyrics:     lyrics,
+			Source:     fmt.Sprintf("Local (%s)", filepath.Base(path)),
+			TrackName:  tags["ti"],
+			ArtistName: tags["ar"],
+		}
+		if length := tags["length"]; length != "" {
+			candidate.Duration = int(lrcTimestampToMs(length) / 1000)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+func (c *LyricsClient) parseLRCContent(content string) (*LyricsResponse, map[string]string) {
+	tags := make(map[string]string)
+	var body []string
+	synced := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := lrcMetadataTagPattern.FindStringSubmatch(trimmed); match != nil {
+			tags[strings.ToLower(match[1])] = strings.TrimSpace(match[2])
+			continue
+		}
+		if lrcTimestampLinePattern.MatchString(trimmed) {
+			synced = true
+		}
+		body = append(body, trimmed)
+	}
+
+	resp := &LRCLibResponse{}
+	if synced {
+		resp.SyncedLyrics = strings.Join(body, "\n")
+	} else {
+		resp.PlainLyrics = strings.Join(body, "\n")
+	}
+
+	return c.convertLRCLibToLyricsResponse(resp), tags
+}
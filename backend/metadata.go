@@ -38,6 +38,10 @@ type Metadata struct {
 	ISRC        string
 	UPC         string
 	Genre       string
+	Version     string
+	Edition     string
+	VersionType string
+	Advisory    string
 }
 
 func resolveMetadataSeparator(separator string) string {
@@ -99,27 +103,15 @@ func joinMultiValueText(values []string, separator string, nullSeparated bool) s
 	return strings.Join(cleaned, displayMetadataSeparator(separator))
 }
 
-func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
-	f, err := flac.ParseFile(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to parse FLAC file: %w", err)
-	}
-
-	var cmtIdx = -1
-	for idx, block := range f.Meta {
-		if block.Type == flac.VorbisComment {
-			cmtIdx = idx
-			break
-		}
-	}
-
+func buildVorbisComment(metadata Metadata) *flacvorbis.MetaDataBlockVorbisComment {
 	cmt := flacvorbis.New()
 	separator := resolveMetadataSeparator(metadata.Separator)
 
 	if metadata.Title != "" {
 		_ = cmt.Add(flacvorbis.FIELD_TITLE, metadata.Title)
 	}
-	if artistValues := SplitArtistCredits(metadata.Artist, separator); len(artistValues) > 0 {
+	artistValues := SplitArtistCredits(metadata.Artist, separator)
+	if len(artistValues) > 0 {
 		addVorbisTagValues(cmt, flacvorbis.FIELD_ARTIST, artistValues)
 	} else if metadata.Artist != "" {
 		_ = cmt.Add(flacvorbis.FIELD_ARTIST, metadata.Artist)
@@ -127,11 +119,20 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 	if metadata.Album != "" {
 		_ = cmt.Add(flacvorbis.FIELD_ALBUM, metadata.Album)
 	}
-	if albumArtistValues := SplitArtistCredits(metadata.AlbumArtist, separator); len(albumArtistValues) > 0 {
+	albumArtistValues := SplitArtistCredits(metadata.AlbumArtist, separator)
+	if len(albumArtistValues) > 0 {
 		addVorbisTagValues(cmt, "ALBUMARTIST", albumArtistValues)
 	} else if metadata.AlbumArtist != "" {
 		_ = cmt.Add("ALBUMARTIST", metadata.AlbumArtist)
 	}
+	if GetMultiValueArtistTagsSetting() {
+		addVorbisTagValues(cmt, "ARTISTS", artistValues)
+		addVorbisTagValues(cmt, "ALBUMARTISTS", albumArtistValues)
+	}
+	if GetArtistSortTagsSetting() {
+		addVorbisTagValues(cmt, "ARTISTSORT", ArtistSortNames(artistValues))
+		addVorbisTagValues(cmt, "ALBUMARTISTSORT", ArtistSortNames(albumArtistValues))
+	}
 	if metadata.Date != "" {
 		_ = cmt.Add(flacvorbis.FIELD_DATE, metadata.Date)
 	}
@@ -158,6 +159,19 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 	} else if metadata.Composer != "" {
 		_ = cmt.Add("COMPOSER", metadata.Composer)
 	}
+	if metadata.Version != "" {
+		_ = cmt.Add("VERSION", metadata.Version)
+	}
+	if metadata.Edition != "" {
+		_ = cmt.Add("EDITION", metadata.Edition)
+	}
+	if versionTypes := SplitMetadataValues(metadata.VersionType, separator); len(versionTypes) > 0 {
+		addVorbisTagValues(cmt, "VERSIONTYPE", versionTypes)
+	}
+	if advisory, explicit := contentAdvisoryTagValues(metadata.Advisory); advisory != "" {
+		_ = cmt.Add("ITUNESADVISORY", advisory)
+		_ = cmt.Add("EXPLICIT", explicit)
+	}
 	if metadata.Description != "" {
 		_ = cmt.Add("DESCRIPTION", metadata.Description)
 	}
@@ -182,6 +196,26 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 		_ = cmt.Add("LYRICS", metadata.Lyrics)
 	}
 
+	return cmt
+}
+
+func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
+	f, err := flac.ParseFile(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	var cmtIdx = -1
+	for idx, block := range f.Meta {
+		if block.Type == flac.VorbisComment {
+			cmtIdx = idx
+			break
+		}
+	}
+
+	metadata = resolveTitleVersionMetadata(metadata)
+	cmt := buildVorbisComment(metadata)
+
 	cmtBlock := cmt.Marshal()
 	if cmtIdx < 0 {
 		f.Meta = append(f.Meta, &cmtBlock)
@@ -194,6 +228,7 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 			fmt.Printf("Warning: Failed to embed cover art: %v\n", err)
 		}
 	}
+	embedExtraArtworkFLAC(f, collectExtraArtwork(filepath, metadata.AlbumArtist))
 
 	if err := f.Save(filepath); err != nil {
 		return fmt.Errorf("failed to save FLAC file: %w", err)
@@ -436,6 +471,10 @@ func extractCoverFromM4AOrFlac(filePath string) (string, error) {
 		return "", fmt.Errorf("no cover art found")
 	}
 
+	if ext == ".m4a" {
+		return extractCoverFromM4A(filePath)
+	}
+
 	return "", nil
 }
 
@@ -452,12 +491,12 @@ func ExtractLyrics(filePath string) (string, error) {
 	case ".flac":
 		lyrics, err = extractLyricsFromFlac(filePath)
 	case ".m4a":
-		return "", nil
+		lyrics, err = extractLyricsFromM4A(filePath)
 	default:
 		return "", fmt.Errorf("unsupported file format: %s", ext)
 	}
 
-	if (err != nil || lyrics == "") && ext != ".m4a" {
+	if err != nil || lyrics == "" {
 		fmt.Printf("[ExtractLyrics] Library extraction failed for %s, trying ffprobe fallback...\n", filePath)
 		ffprobeLyrics, ffprobeErr := extractLyricsWithFFprobe(filePath)
 		if ffprobeErr == nil && ffprobeLyrics != "" {
@@ -576,14 +615,58 @@ func EmbedCoverArtOnly(filePath string, coverPath string) error {
 	switch ext {
 	case ".mp3":
 		return embedCoverToMp3(filePath, coverPath)
+	case ".flac":
+		return embedCoverToFlac(filePath, coverPath)
 	case ".m4a":
-
-		return nil
+		return embedCoverToM4A(filePath, coverPath)
+	case ".opus", ".ogg":
+		return embedCoverToOgg(filePath, coverPath)
+	case ".wav", ".aiff", ".aif":
+		return embedCoverToID3Chunk(filePath, coverPath)
 	default:
 		return fmt.Errorf("unsupported file format: %s", ext)
 	}
 }
 
+func embedCoverToFlac(filePath string, coverPath string) error {
+	f, err := flac.ParseFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	var kept []*flac.MetaDataBlock
+	for _, block := range f.Meta {
+		if block.Type != flac.Picture {
+			continue
+		}
+		if pic, err := flacpicture.ParseFromMetaDataBlock(*block); err == nil && pic.PictureType != flacpicture.PictureTypeFrontCover {
+			kept = append(kept, block)
+		}
+	}
+
+	if err := embedCoverArt(f, coverPath); err != nil {
+		return err
+	}
+	f.Meta = append(f.Meta, kept...)
+
+	if err := f.Save(filePath); err != nil {
+		return fmt.Errorf("failed to save FLAC file: %w", err)
+	}
+
+	return nil
+}
+
+func embedCoverToM4A(filePath string, coverPath string) error {
+	artwork, err := os.ReadFile(coverPath)
+	if err != nil {
+		return fmt.Errorf("failed to read cover art: %w", err)
+	}
+
+	return updateMP4Tags(filePath, func(tags *mp4Tags) {
+		tags.setCover(artwork)
+	})
+}
+
 func embedCoverToMp3(filePath string, coverPath string) error {
 	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
 	if err != nil {
@@ -632,21 +715,32 @@ func EmbedLyricsOnlyMP3(filepath string, lyrics string) error {
 	}
 	defer tag.Close()
 
+	applyID3Lyrics(tag, lyrics)
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save MP3 tags: %w", err)
+	}
+
+	return nil
+}
+
+func applyID3Lyrics(tag *id3v2.Tag, lyrics string) {
+	parsedLyrics, lrcTags := NewLyricsClient().parseLRCContent(lyrics)
+	languageCode := resolveLyricsLanguage(parsedLyrics, lrcTags)
+
 	tag.DeleteFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))
+	tag.DeleteFrames(syncedLyricsFrameID)
 
 	usltFrame := id3v2.UnsynchronisedLyricsFrame{
 		Encoding:          id3v2.EncodingUTF8,
-		Language:          "eng",
+		Language:          languageCode,
 		ContentDescriptor: "",
 		Lyrics:            lyrics,
 	}
 	tag.AddUnsynchronisedLyricsFrame(usltFrame)
-
-	if err := tag.Save(); err != nil {
-		return fmt.Errorf("failed to save MP3 tags: %w", err)
+	if addSyncedLyricsFrame(tag, parsedLyrics, languageCode) {
+		fmt.Printf("[EmbedLyrics] Added SYLT frame (%s)\n", languageCode)
 	}
-
-	return nil
 }
 
 func embedLyricsToM4A(filepath string, lyrics string) error {
@@ -658,48 +752,13 @@ func embedLyricsToM4A(filepath string, lyrics string) error {
 	}
 	lyrics = validatedLyrics
 
-	ffmpegPath, err := GetFFmpegPath()
-	if err != nil {
-		return fmt.Errorf("ffmpeg not found: %w", err)
+	if err := updateMP4Tags(filepath, func(tags *mp4Tags) {
+		tags.setText("\xa9lyr", lyrics)
+	}); err != nil {
+		return fmt.Errorf("failed to embed lyrics: %w", err)
 	}
 
-	if err := ValidateExecutable(ffmpegPath); err != nil {
-		return fmt.Errorf("invalid ffmpeg executable: %w", err)
-	}
-
-	tmpOutputFile := strings.TrimSuffix(filepath, pathfilepath.Ext(filepath)) + ".tmp" + pathfilepath.Ext(filepath)
-	defer func() {
-
-		if _, err := os.Stat(tmpOutputFile); err == nil {
-			os.Remove(tmpOutputFile)
-		}
-	}()
-
-	cmd := exec.Command(ffmpegPath,
-		"-i", filepath,
-		"-map", "0",
-		"-map_metadata", "0",
-		"-metadata", "lyrics-eng="+lyrics,
-		"-metadata", "lyrics="+lyrics,
-		"-codec", "copy",
-		"-f", "ipod",
-		"-y",
-		tmpOutputFile,
-	)
-
-	setHideWindow(cmd)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("[FFmpeg] Error embedding lyrics to M4A: %s\n", string(output))
-		return fmt.Errorf("ffmpeg failed to embed lyrics: %s - %w", string(output), err)
-	}
-
-	if err := os.Rename(tmpOutputFile, filepath); err != nil {
-		return fmt.Errorf("failed to replace original file: %w", err)
-	}
-
-	fmt.Printf("[FFmpeg] Lyrics embedded to M4A successfully: %d characters\n", len(lyrics))
+	fmt.Printf("[EmbedLyrics] Lyrics embedded to M4A successfully: %d characters\n", len(lyrics))
 	return nil
 }
 
@@ -723,54 +782,23 @@ func EmbedLyricsOnlyUniversal(filepath string, lyrics string) error {
 		return EmbedLyricsOnly(filepath, lyrics)
 	case ".m4a":
 		return embedLyricsToM4A(filepath, lyrics)
+	case ".opus", ".ogg":
+		return embedLyricsToOgg(filepath, lyrics)
+	case ".wav", ".aiff", ".aif":
+		return embedLyricsToID3Chunk(filepath, lyrics)
 	default:
 		return fmt.Errorf("unsupported file format for lyrics embedding: %s", ext)
 	}
 }
 
 func GetAudioDuration(filepath string) (float64, error) {
-	ext := strings.ToLower(pathfilepath.Ext(filepath))
-
-	if ext == ".flac" {
-		duration, err := getFlacDuration(filepath)
-		if err == nil && duration > 0 {
-			return duration, nil
-		}
+	if info, err := readNativeAudioInfo(filepath); err == nil && info.Duration > 0 {
+		return info.Duration, nil
 	}
 
 	return getDurationWithFFprobe(filepath)
 }
 
-func getFlacDuration(filepath string) (float64, error) {
-	f, err := flac.ParseFile(filepath)
-	if err != nil {
-		return 0, err
-	}
-
-	if len(f.Meta) > 0 {
-		streamInfo := f.Meta[0]
-		if streamInfo.Type == flac.StreamInfo {
-			data := streamInfo.Data
-			if len(data) >= 18 {
-
-				sampleRate := uint32(data[10])<<12 | uint32(data[11])<<4 | uint32(data[12])>>4
-
-				totalSamples := uint64(data[13]&0x0F)<<32 |
-					uint64(data[14])<<24 |
-					uint64(data[15])<<16 |
-					uint64(data[16])<<8 |
-					uint64(data[17])
-
-				if sampleRate > 0 {
-					return float64(totalSamples) / float64(sampleRate), nil
-				}
-			}
-		}
-	}
-
-	return 0, fmt.Errorf("could not extract duration from FLAC file")
-}
-
 func getDurationWithFFprobe(filepath string) (float64, error) {
 	ffprobePath, err := GetFFprobePath()
 	if err != nil {
@@ -994,6 +1022,21 @@ func ExtractFullMetadataFromFile(filePath string) (Metadata, error) {
 			if metadata.Description == "" {
 				metadata.Description = value
 			}
+		case "version", "tit3", "subtitle":
+			if metadata.Version == "" {
+				metadata.Version = value
+			}
+		case "edition":
+			metadata.Edition = value
+		case "versiontype":
+			metadata.VersionType = value
+		case "itunesadvisory", "rating":
+			switch value {
+			case "1", "4":
+				metadata.Advisory = contentAdvisoryExplicit
+			case "2":
+				metadata.Advisory = contentAdvisoryClean
+			}
 		}
 	}
 
@@ -1014,6 +1057,10 @@ func EmbedMetadataToConvertedFile(filePath string, metadata Metadata, coverPath
 		return embedMetadataToMP3(filePath, metadata, coverPath)
 	case ".m4a":
 		return embedMetadataToM4A(filePath, metadata, coverPath)
+	case ".opus", ".ogg":
+		return embedMetadataToOgg(filePath, metadata, coverPath)
+	case ".wav", ".aiff", ".aif":
+		return embedMetadataToID3Chunk(filePath, metadata, coverPath)
 	default:
 		return fmt.Errorf("unsupported file format: %s", ext)
 	}
@@ -1025,6 +1072,17 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 		return fmt.Errorf("failed to open MP3 file: %w", err)
 	}
 	defer tag.Close()
+	applyID3Metadata(tag, filePath, metadata, coverPath)
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save MP3 tags: %w", err)
+	}
+
+	return nil
+}
+
+func applyID3Metadata(tag *id3v2.Tag, filePath string, metadata Metadata, coverPath string) {
+	metadata = resolveTitleVersionMetadata(metadata)
 	separator := resolveMetadataSeparator(metadata.Separator)
 
 	tag.DeleteFrames("TXXX")
@@ -1043,18 +1101,39 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 		tag.SetYear(year)
 	}
 
-	artistText := joinMultiValueText(SplitArtistCredits(metadata.Artist, separator), separator, true)
+	artistValues := SplitArtistCredits(metadata.Artist, separator)
+	artistText := joinMultiValueText(artistValues, separator, true)
 	if artistText == "" {
 		artistText = strings.TrimSpace(metadata.Artist)
 	}
 	addMP3TextFrame(tag, "TPE1", artistText)
 
-	albumArtistText := joinMultiValueText(SplitArtistCredits(metadata.AlbumArtist, separator), separator, true)
+	albumArtistValues := SplitArtistCredits(metadata.AlbumArtist, separator)
+	albumArtistText := joinMultiValueText(albumArtistValues, separator, true)
 	if albumArtistText == "" {
 		albumArtistText = strings.TrimSpace(metadata.AlbumArtist)
 	}
 	addMP3TextFrame(tag, "TPE2", albumArtistText)
 
+	if GetMultiValueArtistTagsSetting() && len(artistValues) > 0 {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "ARTISTS",
+			Value:       joinMultiValueText(artistValues, separator, true),
+		})
+	}
+	if GetMultiValueArtistTagsSetting() && len(albumArtistValues) > 0 {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "ALBUMARTISTS",
+			Value:       joinMultiValueText(albumArtistValues, separator, true),
+		})
+	}
+	if GetArtistSortTagsSetting() {
+		addMP3TextFrame(tag, "TSOP", joinMultiValueText(ArtistSortNames(artistValues), separator, true))
+		addMP3TextFrame(tag, "TSO2", joinMultiValueText(ArtistSortNames(albumArtistValues), separator, true))
+	}
+
 	if metadata.TrackNumber > 0 {
 		tag.DeleteFrames(tag.CommonID("Track number/Position in set"))
 		trackStr := strconv.Itoa(metadata.TrackNumber)
@@ -1098,6 +1177,34 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 		})
 	}
 
+	addMP3TextFrame(tag, "TIT3", metadata.Version)
+	if metadata.Edition != "" {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "EDITION",
+			Value:       metadata.Edition,
+		})
+	}
+	if versionTypeText := joinMultiValueText(SplitMetadataValues(metadata.VersionType, separator), separator, true); versionTypeText != "" {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "VERSIONTYPE",
+			Value:       versionTypeText,
+		})
+	}
+	if advisory, explicit := contentAdvisoryTagValues(metadata.Advisory); advisory != "" {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "ITUNESADVISORY",
+			Value:       advisory,
+		})
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "EXPLICIT",
+			Value:       explicit,
+		})
+	}
+
 	if comment := resolveMetadataComment(metadata); comment != "" {
 		tag.DeleteFrames(tag.CommonID("Comments"))
 		tag.AddCommentFrame(id3v2.CommentFrame{
@@ -1126,130 +1233,17 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 			fmt.Printf("[EmbedMetadataToMP3] Warning: Failed to read cover art file: %v\n", err)
 		}
 	}
+	embedExtraArtworkMP3(tag, collectExtraArtwork(filePath, metadata.AlbumArtist))
 
 	genreText := joinMultiValueText(SplitMetadataValues(metadata.Genre, separator), separator, true)
 	if genreText == "" {
 		genreText = strings.TrimSpace(metadata.Genre)
 	}
 	addMP3TextFrame(tag, "TCON", genreText)
-
-	if err := tag.Save(); err != nil {
-		return fmt.Errorf("failed to save MP3 tags: %w", err)
-	}
-
-	return nil
 }
 
 func embedMetadataToM4A(filePath string, metadata Metadata, coverPath string) error {
-	ffmpegPath, err := GetFFmpegPath()
-	if err != nil {
-		return fmt.Errorf("ffmpeg not found: %w", err)
-	}
-
-	if err := ValidateExecutable(ffmpegPath); err != nil {
-		return fmt.Errorf("invalid ffmpeg executable: %w", err)
-	}
-
-	args := []string{
-		"-i", filePath,
-		"-y",
-	}
-	separator := resolveMetadataSeparator(metadata.Separator)
-
-	if coverPath != "" && fileExists(coverPath) {
-		args = append(args, "-i", coverPath)
-		args = append(args, "-map", "0:a", "-map", "1", "-c:a", "copy", "-c:v", "copy", "-disposition:v:0", "attached_pic")
-	} else {
-		args = append(args, "-map", "0", "-codec", "copy")
-	}
-
-	if metadata.Title != "" {
-		args = append(args, "-metadata", "title="+metadata.Title)
-	}
-	artistText := joinMultiValueText(SplitArtistCredits(metadata.Artist, separator), separator, false)
-	if artistText == "" {
-		artistText = strings.TrimSpace(metadata.Artist)
-	}
-	if artistText != "" {
-		args = append(args, "-metadata", "artist="+artistText)
-	}
-	if metadata.Album != "" {
-		args = append(args, "-metadata", "album="+metadata.Album)
-	}
-	albumArtistText := joinMultiValueText(SplitArtistCredits(metadata.AlbumArtist, separator), separator, false)
-	if albumArtistText == "" {
-		albumArtistText = strings.TrimSpace(metadata.AlbumArtist)
-	}
-	if albumArtistText != "" {
-		args = append(args, "-metadata", "album_artist="+albumArtistText)
-	}
-	if metadata.Date != "" {
-		args = append(args, "-metadata", "date="+metadata.Date)
-	}
-	if metadata.TrackNumber > 0 {
-		trackStr := strconv.Itoa(metadata.TrackNumber)
-		if metadata.TotalTracks > 0 {
-			trackStr = fmt.Sprintf("%d/%d", metadata.TrackNumber, metadata.TotalTracks)
-		}
-		args = append(args, "-metadata", "track="+trackStr)
-	}
-	if metadata.DiscNumber > 0 {
-		discStr := strconv.Itoa(metadata.DiscNumber)
-		if metadata.TotalDiscs > 0 {
-			discStr = fmt.Sprintf("%d/%d", metadata.DiscNumber, metadata.TotalDiscs)
-		}
-		args = append(args, "-metadata", "disk="+discStr)
-	}
-	if metadata.Copyright != "" {
-		args = append(args, "-metadata", "copyright="+metadata.Copyright)
-	}
-	if metadata.Publisher != "" {
-		args = append(args, "-metadata", "publisher="+metadata.Publisher)
-	}
-	composerText := joinMultiValueText(SplitArtistCredits(metadata.Composer, separator), separator, false)
-	if composerText == "" {
-		composerText = strings.TrimSpace(metadata.Composer)
-	}
-	if composerText != "" {
-		args = append(args, "-metadata", "composer="+composerText)
-	}
-	if metadata.ISRC != "" {
-		args = append(args, "-metadata", "isrc="+metadata.ISRC)
-	}
-	if metadata.UPC != "" {
-		args = append(args, "-metadata", "upc="+metadata.UPC)
-	}
-	genreText := joinMultiValueText(SplitMetadataValues(metadata.Genre, separator), separator, false)
-	if genreText == "" {
-		genreText = strings.TrimSpace(metadata.Genre)
-	}
-	if genreText != "" {
-		args = append(args, "-metadata", "genre="+genreText)
-	}
-	if comment := resolveMetadataComment(metadata); comment != "" {
-		args = append(args, "-metadata", "comment="+comment)
-	}
-
-	tmpOutputFile := strings.TrimSuffix(filePath, pathfilepath.Ext(filePath)) + ".tmp" + pathfilepath.Ext(filePath)
-	defer func() {
-		if _, err := os.Stat(tmpOutputFile); err == nil {
-			os.Remove(tmpOutputFile)
-		}
-	}()
-
-	args = append(args, "-f", "ipod", tmpOutputFile)
-
-	cmd := exec.Command(ffmpegPath, args...)
-	setHideWindow(cmd)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg failed to embed metadata: %s - %w", string(output), err)
-	}
-
-	if err := os.Rename(tmpOutputFile, filePath); err != nil {
-		return fmt.Errorf("failed to replace original file: %w", err)
-	}
-
-	return nil
+	return updateMP4Tags(filePath, func(tags *mp4Tags) {
+		applyMP4Metadata(tags, metadata, coverPath)
+	})
 }
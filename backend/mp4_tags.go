@@ -0,0 +1,727 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	pathfilepath "path/filepath"
+	"strings"
+)
+
+const (
+	mp4DataTypeImplicit = 0
+	mp4DataTypeUTF8     = 1
+	mp4DataTypeJPEG     = 13
+	mp4DataTypePNG      = 14
+	mp4DataTypeInteger  = 21
+
+	mp4FreeformMean = "com.apple.iTunes"
+	mp4TagPadding   = 4096
+)
+
+var mp4ContainerBoxes = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"moof": true,
+	"traf": true,
+	"mfra": true,
+}
+
+type mp4Atom struct {
+	typ        string
+	offset     int64
+	size       int64
+	headerSize int64
+}
+
+type mp4Box struct {
+	typ     string
+	payload []byte
+}
+
+type mp4Item struct {
+	key     string
+	payload []byte
+}
+
+type mp4Tags struct {
+	items []mp4Item
+}
+
+func readMP4Atoms(r io.ReaderAt, start, end int64) ([]mp4Atom, error) {
+	var atoms []mp4Atom
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, fmt.Errorf("failed to read atom header: %w", err)
+		}
+
+		atom := mp4Atom{
+			typ:        string(header[4:8]),
+			offset:     offset,
+			size:       int64(binary.BigEndian.Uint32(header[:4])),
+			headerSize: 8,
+		}
+		switch atom.size {
+		case 0:
+			atom.size = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, fmt.Errorf("failed to read atom size: %w", err)
+			}
+			atom.size = int64(binary.BigEndian.Uint64(header[8:16]))
+			atom.headerSize = 16
+		}
+		if atom.size < atom.headerSize || offset+atom.size > end {
+			return nil, fmt.Errorf("invalid %q atom at offset %d", atom.typ, offset)
+		}
+
+		atoms = append(atoms, atom)
+		offset += atom.size
+	}
+	return atoms, nil
+}
+
+func parseMP4Boxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box")
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated box")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid %q box", string(data[4:8]))
+		}
+
+		boxes = append(boxes, mp4Box{typ: string(data[4:8]), payload: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+func marshalMP4Box(typ string, payload []byte) []byte {
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out[:4], uint32(8+len(payload)))
+	copy(out[4:8], typ)
+	return append(out, payload...)
+}
+
+func marshalMP4Boxes(boxes []mp4Box) []byte {
+	var out []byte
+	for _, box := range boxes {
+		out = append(out, marshalMP4Box(box.typ, box.payload)...)
+	}
+	return out
+}
+
+func findMP4Box(boxes []mp4Box, typ string) int {
+	for i, box := range boxes {
+		if box.typ == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+func mp4FullBoxPayload(payload []byte) []byte {
+	header := make([]byte, 4)
+	return append(header, payload...)
+}
+
+func mp4DataBox(dataType uint32, value []byte) []byte {
+	payload := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint32(payload[:4], dataType)
+	return marshalMP4Box("data", append(payload, value...))
+}
+
+func parseMP4Item(box mp4Box) mp4Item {
+	item := mp4Item{key: box.typ, payload: box.payload}
+	if box.typ != "----" {
+		return item
+	}
+
+	children, err := parseMP4Boxes(box.payload)
+	if err != nil {
+		return item
+	}
+	var mean, name string
+	for _, child := range children {
+		if len(child.payload) < 4 {
+			continue
+		}
+		switch child.typ {
+		case "mean":
+			mean = string(child.payload[4:])
+		case "name":
+			name = string(child.payload[4:])
+		}
+	}
+	item.key = "----:" + mean + ":" + name
+	return item
+}
+
+func (item mp4Item) values() [][]byte {
+	children, err := parseMP4Boxes(item.payload)
+	if err != nil {
+		return nil
+	}
+
+	var values [][]byte
+	for _, child := range children {
+		if child.typ == "data" && len(child.payload) >= 8 {
+			values = append(values, child.payload[8:])
+		}
+	}
+	return values
+}
+
+func (t *mp4Tags) find(key string) *mp4Item {
+	for i := range t.items {
+		if strings.EqualFold(t.items[i].key, key) {
+			return &t.items[i]
+		}
+	}
+	return nil
+}
+
+func (t *mp4Tags) remove(key string) {
+	kept := t.items[:0]
+	for _, item := range t.items {
+		if !strings.EqualFold(item.key, key) {
+			kept = append(kept, item)
+		}
+	}
+	t.items = kept
+}
+
+func (t *mp4Tags) set(key string, payload []byte) {
+	t.remove(key)
+	t.items = append(t.items, mp4Item{key: key, payload: payload})
+}
+
+func (t *mp4Tags) setText(key, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		t.remove(key)
+		return
+	}
+	t.set(key, mp4DataBox(mp4DataTypeUTF8, []byte(value)))
+}
+
+func (t *mp4Tags) setFreeform(name string, values ...string) {
+	key := "----:" + mp4FreeformMean + ":" + name
+	var payload []byte
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			payload = append(payload, mp4DataBox(mp4DataTypeUTF8, []byte(value))...)
+		}
+	}
+	if len(payload) == 0 {
+		t.remove(key)
+		return
+	}
+
+	header := marshalMP4Box("mean", mp4FullBoxPayload([]byte(mp4FreeformMean)))
+	header = append(header, marshalMP4Box("name", mp4FullBoxPayload([]byte(name)))...)
+	t.set(key, append(header, payload...))
+}
+
+func (t *mp4Tags) setPair(key string, number, total int, trailing int) {
+	if number <= 0 {
+		t.remove(key)
+		return
+	}
+	value := make([]byte, 6+trailing)
+	binary.BigEndian.PutUint16(value[2:4], uint16(number))
+	binary.BigEndian.PutUint16(value[4:6], uint16(max(total, 0)))
+	t.set(key, mp4DataBox(mp4DataTypeImplicit, value))
+}
+
+func (t *mp4Tags) setByte(key string, value byte) {
+	t.set(key, mp4DataBox(mp4DataTypeInteger, []byte{value}))
+}
+
+func (t *mp4Tags) setCover(artwork []byte) {
+	dataType := uint32(mp4DataTypeJPEG)
+	if artworkMimeType(artwork) == "image/png" {
+		dataType = mp4DataTypePNG
+	}
+	t.set("covr", mp4DataBox(dataType, artwork))
+}
+
+func (t *mp4Tags) text(key string) string {
+	item := t.find(key)
+	if item == nil {
+		return ""
+	}
+	if values := item.values(); len(values) > 0 {
+		return string(values[0])
+	}
+	return ""
+}
+
+func (t *mp4Tags) cover() []byte {
+	item := t.find("covr")
+	if item == nil {
+		return nil
+	}
+	if values := item.values(); len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
+
+func (t *mp4Tags) marshal() []byte {
+	var out []byte
+	for _, item := range t.items {
+		typ := item.key
+		if strings.HasPrefix(typ, "----:") {
+			typ = "----"
+		}
+		out = append(out, marshalMP4Box(typ, item.payload)...)
+	}
+	return out
+}
+
+func splitMP4Meta(payload []byte) ([]byte, []mp4Box, error) {
+	var prefix []byte
+	if len(payload) >= 8 && string(payload[4:8]) != "hdlr" {
+		prefix, payload = payload[:4], payload[4:]
+	}
+	boxes, err := parseMP4Boxes(payload)
+	return prefix, boxes, err
+}
+
+func newMP4MetaBoxes() []mp4Box {
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:12], "mdir")
+	copy(hdlr[12:16], "appl")
+	return []mp4Box{{typ: "hdlr", payload: hdlr}}
+}
+
+func readMP4Tags(moov []byte) (*mp4Tags, error) {
+	tags := &mp4Tags{}
+	moovBoxes, err := parseMP4Boxes(moov)
+	if err != nil {
+		return nil, err
+	}
+	udta := findMP4Box(moovBoxes, "udta")
+	if udta < 0 {
+		return tags, nil
+	}
+	udtaBoxes, err := parseMP4Boxes(moovBoxes[udta].payload)
+	if err != nil {
+		return nil, err
+	}
+	meta := findMP4Box(udtaBoxes, "meta")
+	if meta < 0 {
+		return tags, nil
+	}
+	_, metaBoxes, err := splitMP4Meta(udtaBoxes[meta].payload)
+	if err != nil {
+		return nil, err
+	}
+	ilst := findMP4Box(metaBoxes, "ilst")
+	if ilst < 0 {
+		return tags, nil
+	}
+	items, err := parseMP4Boxes(metaBoxes[ilst].payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		tags.items = append(tags.items, parseMP4Item(item))
+	}
+	return tags, nil
+}
+
+func buildMP4Moov(moov []byte, tags *mp4Tags, padding int) ([]byte, error) {
+	moovBoxes, err := parseMP4Boxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	var udtaBoxes []mp4Box
+	udta := findMP4Box(moovBoxes, "udta")
+	if udta >= 0 {
+		if udtaBoxes, err = parseMP4Boxes(moovBoxes[udta].payload); err != nil {
+			return nil, err
+		}
+	} else {
+		moovBoxes = append(moovBoxes, mp4Box{typ: "udta"})
+		udta = len(moovBoxes) - 1
+	}
+
+	metaPrefix := make([]byte, 4)
+	metaBoxes := newMP4MetaBoxes()
+	meta := findMP4Box(udtaBoxes, "meta")
+	if meta >= 0 {
+		if metaPrefix, metaBoxes, err = splitMP4Meta(udtaBoxes[meta].payload); err != nil {
+			return nil, err
+		}
+	} else {
+		udtaBoxes = append(udtaBoxes, mp4Box{typ: "meta"})
+		meta = len(udtaBoxes) - 1
+	}
+
+	var rebuilt []mp4Box
+	for _, box := range metaBoxes {
+		if box.typ != "ilst" && box.typ != "free" && box.typ != "skip" {
+			rebuilt = append(rebuilt, box)
+		}
+	}
+	rebuilt = append(rebuilt, mp4Box{typ: "ilst", payload: tags.marshal()})
+	if padding >= 8 {
+		rebuilt = append(rebuilt, mp4Box{typ: "free", payload: make([]byte, padding-8)})
+	}
+
+	udtaBoxes[meta].payload = append(append([]byte{}, metaPrefix...), marshalMP4Boxes(rebuilt)...)
+	moovBoxes[udta].payload = marshalMP4Boxes(udtaBoxes)
+	return marshalMP4Boxes(moovBoxes), nil
+}
+
+func adjustMP4Offsets(boxes []mp4Box, threshold, delta int64) error {
+	for _, box := range boxes {
+		p := box.payload
+		switch {
+		case mp4ContainerBoxes[box.typ]:
+			children, err := parseMP4Boxes(p)
+			if err != nil {
+				return err
+			}
+			if err := adjustMP4Offsets(children, threshold, delta); err != nil {
+				return err
+			}
+		case box.typ == "stco" && len(p) >= 8:
+			count := int(binary.BigEndian.Uint32(p[4:8]))
+			for i := 0; i < count && 8+i*4+4 <= len(p); i++ {
+				field := p[8+i*4 : 8+i*4+4]
+				if offset := int64(binary.BigEndian.Uint32(field)); offset >= threshold {
+					if offset+delta > 0xFFFFFFFF {
+						return fmt.Errorf("chunk offset overflow")
+					}
+					binary.BigEndian.PutUint32(field, uint32(offset+delta))
+				}
+			}
+		case box.typ == "co64" && len(p) >= 8:
+			count := int(binary.BigEndian.Uint32(p[4:8]))
+			for i := 0; i < count && 8+i*8+8 <= len(p); i++ {
+				field := p[8+i*8 : 8+i*8+8]
+				if offset := int64(binary.BigEndian.Uint64(field)); offset >= threshold {
+					binary.BigEndian.PutUint64(field, uint64(offset+delta))
+				}
+			}
+		case box.typ == "tfhd" && len(p) >= 16 && p[3]&0x01 != 0:
+			field := p[8:16]
+			if offset := int64(binary.BigEndian.Uint64(field)); offset >= threshold {
+				binary.BigEndian.PutUint64(field, uint64(offset+delta))
+			}
+		case box.typ == "tfra" && len(p) >= 16:
+			sizes := binary.BigEndian.Uint32(p[8:12])
+			entrySize := 8
+			if p[0] == 1 {
+				entrySize = 16
+			}
+			fieldSize := entrySize / 2
+			entrySize += int(sizes>>4&3+1) + int(sizes>>2&3+1) + int(sizes&3+1)
+			count := int(binary.BigEndian.Uint32(p[12:16]))
+			for i := 0; i < count && 16+(i+1)*entrySize <= len(p); i++ {
+				field := p[16+i*entrySize+fieldSize : 16+i*entrySize+2*fieldSize]
+				if fieldSize == 8 {
+					if offset := int64(binary.BigEndian.Uint64(field)); offset >= threshold {
+						binary.BigEndian.PutUint64(field, uint64(offset+delta))
+					}
+				} else if offset := int64(binary.BigEndian.Uint32(field)); offset >= threshold {
+					binary.BigEndian.PutUint32(field, uint32(offset+delta))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func readMP4TagsFromFile(filePath string) (*mp4Tags, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open M4A file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	atoms, err := readMP4Atoms(f, 0, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	for _, atom := range atoms {
+		if atom.typ != "moov" {
+			continue
+		}
+		moov := make([]byte, atom.size-atom.headerSize)
+		if _, err := f.ReadAt(moov, atom.offset+atom.headerSize); err != nil {
+			return nil, fmt.Errorf("failed to read moov atom: %w", err)
+		}
+		return readMP4Tags(moov)
+	}
+	return nil, fmt.Errorf("no moov atom found")
+}
+
+func updateMP4Tags(filePath string, apply func(tags *mp4Tags)) error {
+	f, err := os.OpenFile(filePath, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open M4A file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	atoms, err := readMP4Atoms(f, 0, info.Size())
+	if err != nil {
+		return err
+	}
+
+	moovIndex := -1
+	for i, atom := range atoms {
+		if atom.typ == "moov" {
+			moovIndex = i
+			break
+		}
+	}
+	if moovIndex < 0 {
+		return fmt.Errorf("no moov atom found")
+	}
+	moovAtom := atoms[moovIndex]
+
+	moov := make([]byte, moovAtom.size-moovAtom.headerSize)
+	if _, err := f.ReadAt(moov, moovAtom.offset+moovAtom.headerSize); err != nil {
+		return fmt.Errorf("failed to read moov atom: %w", err)
+	}
+
+	tags, err := readMP4Tags(moov)
+	if err != nil {
+		return fmt.Errorf("failed to parse M4A tags: %w", err)
+	}
+	apply(tags)
+
+	unpadded, err := buildMP4Moov(moov, tags, 0)
+	if err != nil {
+		return err
+	}
+
+	available := moovAtom.size
+	if moovIndex+1 < len(atoms) {
+		if next := atoms[moovIndex+1]; next.typ == "free" || next.typ == "skip" {
+			available += next.size
+		}
+	}
+
+	needed := int64(8 + len(unpadded))
+	if needed == moovAtom.size || needed+8 <= moovAtom.size {
+		padded, err := buildMP4Moov(moov, tags, int(moovAtom.size-needed))
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteAt(marshalMP4Box("moov", padded), moovAtom.offset); err != nil {
+			return fmt.Errorf("failed to write moov atom: %w", err)
+		}
+		return nil
+	}
+	if needed == available || needed+8 <= available {
+		out := marshalMP4Box("moov", unpadded)
+		if rest := available - needed; rest > 0 {
+			out = append(out, marshalMP4Box("free", make([]byte, rest-8))...)
+		}
+		if _, err := f.WriteAt(out, moovAtom.offset); err != nil {
+			return fmt.Errorf("failed to write moov atom: %w", err)
+		}
+		return nil
+	}
+
+	padded, err := buildMP4Moov(moov, tags, mp4TagPadding)
+	if err != nil {
+		return err
+	}
+	delta := int64(8+len(padded)) - moovAtom.size
+	threshold := moovAtom.offset + moovAtom.size
+
+	moovBoxes, err := parseMP4Boxes(padded)
+	if err != nil {
+		return err
+	}
+	if err := adjustMP4Offsets(moovBoxes, threshold, delta); err != nil {
+		return err
+	}
+	newMoov := marshalMP4Box("moov", padded)
+
+	tmpPath := strings.TrimSuffix(filePath, pathfilepath.Ext(filePath)) + ".tmp" + pathfilepath.Ext(filePath)
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if _, err := os.Stat(tmpPath); err == nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := bufio.NewWriter(out)
+	writeErr := func() error {
+		for _, atom := range atoms {
+			switch atom.typ {
+			case "moov":
+				if _, err := writer.Write(newMoov); err != nil {
+					return err
+				}
+			case "moof", "mfra":
+				data := make([]byte, atom.size)
+				if _, err := f.ReadAt(data, atom.offset); err != nil {
+					return err
+				}
+				boxes, err := parseMP4Boxes(data)
+				if err != nil {
+					return err
+				}
+				if err := adjustMP4Offsets(boxes, threshold, delta); err != nil {
+					return err
+				}
+				if _, err := writer.Write(data); err != nil {
+					return err
+				}
+			default:
+				if _, err := io.Copy(writer, io.NewSectionReader(f, atom.offset, atom.size)); err != nil {
+					return err
+				}
+			}
+		}
+		return writer.Flush()
+	}()
+	if closeErr := out.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write M4A file: %w", writeErr)
+	}
+
+	f.Close()
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to replace original file: %w", err)
+	}
+	return nil
+}
+
+func applyMP4Metadata(tags *mp4Tags, metadata Metadata, coverPath string) {
+	metadata = resolveTitleVersionMetadata(metadata)
+	separator := resolveMetadataSeparator(metadata.Separator)
+
+	tags.setText("\xa9nam", metadata.Title)
+	artistValues := SplitArtistCredits(metadata.Artist, separator)
+	artistText := joinMultiValueText(artistValues, separator, false)
+	if artistText == "" {
+		artistText = strings.TrimSpace(metadata.Artist)
+	}
+	tags.setText("\xa9ART", artistText)
+	tags.setText("\xa9alb", metadata.Album)
+	albumArtistValues := SplitArtistCredits(metadata.AlbumArtist, separator)
+	albumArtistText := joinMultiValueText(albumArtistValues, separator, false)
+	if albumArtistText == "" {
+		albumArtistText = strings.TrimSpace(metadata.AlbumArtist)
+	}
+	tags.setText("aART", albumArtistText)
+	if GetMultiValueArtistTagsSetting() {
+		tags.setFreeform("ARTISTS", artistValues...)
+		tags.setFreeform("ALBUMARTISTS", albumArtistValues...)
+	}
+	if GetArtistSortTagsSetting() {
+		tags.setText("soar", joinMultiValueText(ArtistSortNames(artistValues), separator, false))
+		tags.setText("soaa", joinMultiValueText(ArtistSortNames(albumArtistValues), separator, false))
+	}
+	tags.setText("\xa9day", metadata.Date)
+	if advisory, _ := contentAdvisoryTagValues(metadata.Advisory); advisory != "" {
+		tags.setByte("rtng", advisory[0]-'0')
+		tags.setFreeform("ITUNESADVISORY", advisory)
+	} else {
+		tags.remove("rtng")
+		tags.setFreeform("ITUNESADVISORY")
+	}
+	tags.setPair("trkn", metadata.TrackNumber, metadata.TotalTracks, 2)
+	tags.setPair("disk", metadata.DiscNumber, metadata.TotalDiscs, 0)
+	tags.setText("cprt", metadata.Copyright)
+	tags.setFreeform("LABEL", metadata.Publisher)
+	composerText := joinMultiValueText(SplitArtistCredits(metadata.Composer, separator), separator, false)
+	if composerText == "" {
+		composerText = strings.TrimSpace(metadata.Composer)
+	}
+	tags.setText("\xa9wrt", composerText)
+	tags.setFreeform("ISRC", metadata.ISRC)
+	tags.setFreeform(preferredUPCTagKey, metadata.UPC)
+	tags.setFreeform("SUBTITLE", metadata.Version)
+	tags.setFreeform("EDITION", metadata.Edition)
+	tags.setFreeform("VERSIONTYPE", SplitMetadataValues(metadata.VersionType, separator)...)
+	genreText := joinMultiValueText(SplitMetadataValues(metadata.Genre, separator), separator, false)
+	if genreText == "" {
+		genreText = strings.TrimSpace(metadata.Genre)
+	}
+	tags.setText("\xa9gen", genreText)
+	tags.setText("desc", metadata.Description)
+	tags.setText("\xa9cmt", resolveMetadataComment(metadata))
+
+	if coverPath != "" && fileExists(coverPath) {
+		if artwork, err := os.ReadFile(coverPath); err == nil {
+			tags.setCover(artwork)
+		} else {
+			fmt.Printf("[EmbedMetadataToM4A] Warning: Failed to read cover art file: %v\n", err)
+		}
+	}
+}
+
+func extractCoverFromM4A(filePath string) (string, error) {
+	tags, err := readMP4TagsFromFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	artwork := tags.cover()
+	if len(artwork) == 0 {
+		return "", fmt.Errorf("no cover art found")
+	}
+
+	tmpFile, err := os.CreateTemp("", "cover-*.jpg")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.Write(artwork); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write cover art: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+func extractLyricsFromM4A(filePath string) (string, error) {
+	tags, err := readMP4TagsFromFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return tags.text("\xa9lyr"), nil
+}
@@ -0,0 +1,215 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testMP4Samples = [][]byte{
+	bytes.Repeat([]byte{0xA1}, 400),
+	bytes.Repeat([]byte{0xB2}, 300),
+	bytes.Repeat([]byte{0xC3}, 300),
+}
+
+func buildTestMP4Trak(chunkOffset uint32) []byte {
+	mdhd := make([]byte, 24)
+	binary.BigEndian.PutUint32(mdhd[12:16], 1000)
+	binary.BigEndian.PutUint32(mdhd[16:20], 2000)
+
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:12], "soun")
+
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[6:8], 1)
+	binary.BigEndian.PutUint16(entry[16:18], 2)
+	binary.BigEndian.PutUint16(entry[18:20], 16)
+	binary.BigEndian.PutUint16(entry[24:26], 44100)
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:8], 1)
+	stsd = append(stsd, marshalMP4Box("mp4a", entry)...)
+
+	stsz := make([]byte, 12)
+	binary.BigEndian.PutUint32(stsz[8:12], uint32(len(testMP4Samples)))
+	for _, sample := range testMP4Samples {
+		stsz = binary.BigEndian.AppendUint32(stsz, uint32(len(sample)))
+	}
+
+	stco := make([]byte, 8)
+	binary.BigEndian.PutUint32(stco[4:8], 1)
+	stco = binary.BigEndian.AppendUint32(stco, chunkOffset)
+
+	stbl := marshalMP4Boxes([]mp4Box{
+		{typ: "stsd", payload: stsd},
+		{typ: "stsz", payload: stsz},
+		{typ: "stco", payload: stco},
+	})
+	minf := marshalMP4Box("stbl", stbl)
+	mdia := marshalMP4Boxes([]mp4Box{
+		{typ: "mdhd", payload: mdhd},
+		{typ: "hdlr", payload: hdlr},
+		{typ: "minf", payload: minf},
+	})
+	return marshalMP4Box("mdia", mdia)
+}
+
+func writeTestM4A(t *testing.T, padding int) string {
+	t.Helper()
+
+	ftyp := marshalMP4Box("ftyp", []byte("M4A \x00\x00\x00\x00M4A mp42isom"))
+	moovSize := len(marshalMP4Box("moov", marshalMP4Box("trak", buildTestMP4Trak(0))))
+	tail := 0
+	if padding > 0 {
+		tail = 8 + padding
+	}
+	chunkOffset := uint32(len(ftyp) + moovSize + tail + 8)
+
+	moov := marshalMP4Box("moov", marshalMP4Box("trak", buildTestMP4Trak(chunkOffset)))
+	data := append(append([]byte{}, ftyp...), moov...)
+	if padding > 0 {
+		data = append(data, marshalMP4Box("free", make([]byte, padding))...)
+	}
+	data = append(data, marshalMP4Box("mdat", bytes.Join(testMP4Samples, nil))...)
+
+	path := filepath.Join(t.TempDir(), "track.m4a")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readTestMP4ChunkOffset(t *testing.T, path string) int64 {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	atoms, err := readMP4Atoms(f, 0, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, atom := range atoms {
+		if atom.typ != "moov" {
+			continue
+		}
+		moov := make([]byte, atom.size-atom.headerSize)
+		if _, err := f.ReadAt(moov, atom.offset+atom.headerSize); err != nil {
+			t.Fatal(err)
+		}
+		stco, err := mp4ChildPayload(moov, "trak", "mdia", "minf", "stbl", "stco")
+		if err != nil || len(stco) < 12 {
+			t.Fatalf("missing stco box: %v", err)
+		}
+		return int64(binary.BigEndian.Uint32(stco[8:12]))
+	}
+	t.Fatal("no moov atom found")
+	return 0
+}
+
+func assertTestMP4Samples(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := readTestMP4ChunkOffset(t, path)
+	want := bytes.Join(testMP4Samples, nil)
+	if offset < 0 || offset+int64(len(want)) > int64(len(data)) {
+		t.Fatalf("chunk offset %d out of range for %d byte file", offset, len(data))
+	}
+	if !bytes.Equal(data[offset:offset+int64(len(want))], want) {
+		t.Fatalf("chunk offset %d does not point at the sample data", offset)
+	}
+}
+
+func TestUpdateMP4TagsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		padding int
+		cover   int
+		moved   bool
+	}{
+		{name: "fits in padding", padding: 8192, cover: 1024, moved: false},
+		{name: "moov grows before mdat", padding: 0, cover: 1024, moved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestM4A(t, tt.padding)
+			before := readTestMP4ChunkOffset(t, path)
+			assertTestMP4Samples(t, path)
+
+			cover := append([]byte{0xFF, 0xD8, 0xFF}, make([]byte, tt.cover)...)
+			err := updateMP4Tags(path, func(tags *mp4Tags) {
+				tags.setText("\xa9nam", "Title")
+				tags.setText("\xa9ART", "Artist")
+				tags.setFreeform("ISRC", "USABC1234567")
+				tags.setPair("trkn", 3, 12, 2)
+				tags.setCover(cover)
+			})
+			if err != nil {
+				t.Fatalf("updateMP4Tags: %v", err)
+			}
+
+			after := readTestMP4ChunkOffset(t, path)
+			if moved := after != before; moved != tt.moved {
+				t.Fatalf("chunk offset moved = %v (%d -> %d), want %v", moved, before, after, tt.moved)
+			}
+			assertTestMP4Samples(t, path)
+
+			tags, err := readMP4TagsFromFile(path)
+			if err != nil {
+				t.Fatalf("readMP4TagsFromFile: %v", err)
+			}
+			if got := tags.text("\xa9nam"); got != "Title" {
+				t.Errorf("title = %q, want %q", got, "Title")
+			}
+			if got := tags.text("----:" + mp4FreeformMean + ":ISRC"); got != "USABC1234567" {
+				t.Errorf("ISRC = %q, want %q", got, "USABC1234567")
+			}
+			if got := tags.cover(); !bytes.Equal(got, cover) {
+				t.Errorf("cover length = %d, want %d", len(got), len(cover))
+			}
+
+			err = updateMP4Tags(path, func(tags *mp4Tags) {
+				tags.setText("\xa9ART", "")
+				tags.setFreeform("ISRC")
+				tags.setPair("trkn", 0, 0, 2)
+			})
+			if err != nil {
+				t.Fatalf("updateMP4Tags: %v", err)
+			}
+			assertTestMP4Samples(t, path)
+
+			tags, err = readMP4TagsFromFile(path)
+			if err != nil {
+				t.Fatalf("readMP4TagsFromFile: %v", err)
+			}
+			for _, key := range []string{"\xa9ART", "----:" + mp4FreeformMean + ":ISRC", "trkn"} {
+				if tags.find(key) != nil {
+					t.Errorf("%q was not removed", key)
+				}
+			}
+			if got := tags.text("\xa9nam"); got != "Title" {
+				t.Errorf("title = %q after clearing other tags, want %q", got, "Title")
+			}
+
+			info, err := readNativeAudioInfo(path)
+			if err != nil {
+				t.Fatalf("readNativeAudioInfo: %v", err)
+			}
+			if want := len(bytes.Join(testMP4Samples, nil)) * 8 / 2; info.Bitrate != want {
+				t.Errorf("bitrate = %d, want %d", info.Bitrate, want)
+			}
+		})
+	}
+}
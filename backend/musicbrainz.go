@@ -5,12 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
-
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
 var AppVersion = "Unknown"
@@ -120,7 +118,7 @@ func musicBrainzCacheKey(isrc string, useSingleGenre bool) string {
 		separator = ";"
 	}
 
-	return strings.ToUpper(strings.TrimSpace(isrc)) + "|" + fmt.Sprintf("%t", useSingleGenre) + "|" + separator
+	return strings.ToUpper(strings.TrimSpace(isrc)) + "|" + fmt.Sprintf("%t|%t|%t", useSingleGenre, GetGenreWhitelistSetting(), GetGenreCollapseToParentSetting()) + "|" + separator
 }
 
 func waitForMusicBrainzRequestSlot() {
@@ -288,36 +286,23 @@ func FetchMusicBrainzMetadata(isrc, title, artist, album string, useSingleGenre
 
 	recording := mbResp.Recordings[0]
 
-	var genres []string
-	caser := cases.Title(language.English)
-
-	if useSingleGenre {
-
-		maxCount := -1
-		var bestTag string
-
-		for _, tag := range recording.Tags {
-			if tag.Count > maxCount {
-				maxCount = tag.Count
-				bestTag = tag.Name
-			}
-		}
+	tags := append(recording.Tags[:0:0], recording.Tags...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Count > tags[j].Count
+	})
 
-		if bestTag != "" {
-			meta.Genre = caser.String(bestTag)
-		}
-	} else {
-		for _, tag := range recording.Tags {
+	rawGenres := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		rawGenres = append(rawGenres, tag.Name)
+	}
 
-			genres = append(genres, caser.String(tag.Name))
-		}
-		if len(genres) > 0 {
+	limit := defaultGenreCount
+	if useSingleGenre {
+		limit = 1
+	}
 
-			if len(genres) > 5 {
-				genres = genres[:5]
-			}
-			meta.Genre = strings.Join(genres, GetSeparator())
-		}
+	if genres := CanonicalizeGenres(rawGenres, limit); len(genres) > 0 {
+		meta.Genre = strings.Join(genres, GetSeparator())
 	}
 
 	if meta.Genre == "" {
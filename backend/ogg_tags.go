@@ -0,0 +1,386 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	pathfilepath "path/filepath"
+	"strings"
+
+	"github.com/go-flac/flacpicture"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+const (
+	oggPageHeaderSize    = 27
+	oggMaxSegments       = 255
+	oggHeaderContinued   = 0x01
+	oggHeaderFirstPage   = 0x02
+	vorbisPictureComment = "METADATA_BLOCK_PICTURE"
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+type oggPage struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	sequence   uint32
+	segments   []byte
+	data       []byte
+}
+
+type oggCodec struct {
+	name          string
+	headerPackets int
+	commentPrefix []byte
+	framingBit    bool
+}
+
+var oggCodecs = []struct {
+	idPrefix []byte
+	codec    oggCodec
+}{
+	{[]byte("OpusHead"), oggCodec{name: "Opus", headerPackets: 2, commentPrefix: []byte("OpusTags")}},
+	{[]byte("\x01vorbis"), oggCodec{name: "Vorbis", headerPackets: 3, commentPrefix: []byte("\x03vorbis"), framingBit: true}},
+}
+
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "OggS" {
+		return nil, fmt.Errorf("invalid Ogg page signature")
+	}
+
+	page := &oggPage{
+		headerType: header[5],
+		granule:    binary.LittleEndian.Uint64(header[6:14]),
+		serial:     binary.LittleEndian.Uint32(header[14:18]),
+		sequence:   binary.LittleEndian.Uint32(header[18:22]),
+		segments:   make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(r, page.segments); err != nil {
+		return nil, fmt.Errorf("truncated Ogg page: %w", err)
+	}
+
+	size := 0
+	for _, segment := range page.segments {
+		size += int(segment)
+	}
+	page.data = make([]byte, size)
+	if _, err := io.ReadFull(r, page.data); err != nil {
+		return nil, fmt.Errorf("truncated Ogg page: %w", err)
+	}
+	return page, nil
+}
+
+func (p *oggPage) marshal() []byte {
+	buf := make([]byte, oggPageHeaderSize, oggPageHeaderSize+len(p.segments)+len(p.data))
+	copy(buf, "OggS")
+	buf[5] = p.headerType
+	binary.LittleEndian.PutUint64(buf[6:14], p.granule)
+	binary.LittleEndian.PutUint32(buf[14:18], p.serial)
+	binary.LittleEndian.PutUint32(buf[18:22], p.sequence)
+	buf[26] = byte(len(p.segments))
+	buf = append(buf, p.segments...)
+	buf = append(buf, p.data...)
+
+	var crc uint32
+	for _, b := range buf {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	binary.LittleEndian.PutUint32(buf[22:26], crc)
+	return buf
+}
+
+func paginateOggPackets(packets [][]byte, serial uint32, sequence uint32) []*oggPage {
+	var pages []*oggPage
+	page := &oggPage{serial: serial, sequence: sequence}
+
+	flush := func(continued bool) {
+		pages = append(pages, page)
+		sequence++
+		page = &oggPage{serial: serial, sequence: sequence}
+		if continued {
+			page.headerType = oggHeaderContinued
+		}
+	}
+
+	for _, packet := range packets {
+		remaining := packet
+		for {
+			if len(page.segments) == oggMaxSegments {
+				flush(true)
+			}
+			n := min(len(remaining), 255)
+			page.segments = append(page.segments, byte(n))
+			page.data = append(page.data, remaining[:n]...)
+			remaining = remaining[n:]
+			if n < 255 {
+				break
+			}
+		}
+		if len(page.segments) == oggMaxSegments {
+			flush(false)
+		}
+	}
+	if len(page.segments) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func readOggHeaderPackets(r io.Reader) (*oggPage, [][]byte, oggCodec, error) {
+	first, err := readOggPage(r)
+	if err != nil {
+		return nil, nil, oggCodec{}, fmt.Errorf("failed to read Ogg stream: %w", err)
+	}
+	if first.headerType&oggHeaderFirstPage == 0 || len(first.segments) == 0 || first.segments[len(first.segments)-1] == 255 {
+		return nil, nil, oggCodec{}, fmt.Errorf("unsupported Ogg stream layout")
+	}
+
+	var codec oggCodec
+	for _, candidate := range oggCodecs {
+		if bytes.HasPrefix(first.data, candidate.idPrefix) {
+			codec = candidate.codec
+			break
+		}
+	}
+	if codec.name == "" {
+		return nil, nil, oggCodec{}, fmt.Errorf("unsupported Ogg codec")
+	}
+
+	var packets [][]byte
+	var current []byte
+	for len(packets) < codec.headerPackets-1 {
+		page, err := readOggPage(r)
+		if err != nil {
+			return nil, nil, codec, fmt.Errorf("failed to read %s headers: %w", codec.name, err)
+		}
+		if page.serial != first.serial {
+			return nil, nil, codec, fmt.Errorf("multiplexed Ogg streams are not supported")
+		}
+
+		offset := 0
+		for i, segment := range page.segments {
+			current = append(current, page.data[offset:offset+int(segment)]...)
+			offset += int(segment)
+			if segment < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == codec.headerPackets-1 && i != len(page.segments)-1 {
+					return nil, nil, codec, fmt.Errorf("%s headers do not end on a page boundary", codec.name)
+				}
+			}
+		}
+	}
+
+	if !bytes.HasPrefix(packets[0], codec.commentPrefix) {
+		return nil, nil, codec, fmt.Errorf("missing %s comment header", codec.name)
+	}
+	return first, packets, codec, nil
+}
+
+func parseOggComment(packet []byte, codec oggCodec) *flacvorbis.MetaDataBlockVorbisComment {
+	cmt, err := flacvorbis.ParseFromMetaDataBlock(flac.MetaDataBlock{
+		Type: flac.VorbisComment,
+		Data: packet[len(codec.commentPrefix):],
+	})
+	if err != nil {
+		return flacvorbis.New()
+	}
+	return cmt
+}
+
+func buildOggCommentPacket(cmt *flacvorbis.MetaDataBlockVorbisComment, codec oggCodec) []byte {
+	block := cmt.Marshal()
+	packet := append([]byte{}, codec.commentPrefix...)
+	packet = append(packet, block.Data...)
+	if codec.framingBit {
+		packet = append(packet, 0x01)
+	}
+	return packet
+}
+
+func updateOggComments(filePath string, update func(cmt *flacvorbis.MetaDataBlockVorbisComment) *flacvorbis.MetaDataBlockVorbisComment) error {
+	in, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open Ogg file: %w", err)
+	}
+	defer in.Close()
+
+	reader := bufio.NewReader(in)
+	first, packets, codec, err := readOggHeaderPackets(reader)
+	if err != nil {
+		return err
+	}
+
+	cmt := update(parseOggComment(packets[0], codec))
+	packets[0] = buildOggCommentPacket(cmt, codec)
+
+	tmpPath := strings.TrimSuffix(filePath, pathfilepath.Ext(filePath)) + ".tmp" + pathfilepath.Ext(filePath)
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if _, err := os.Stat(tmpPath); err == nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := bufio.NewWriter(out)
+	writeErr := func() error {
+		if _, err := writer.Write(first.marshal()); err != nil {
+			return err
+		}
+
+		sequence := first.sequence + 1
+		for _, page := range paginateOggPackets(packets, first.serial, sequence) {
+			if _, err := writer.Write(page.marshal()); err != nil {
+				return err
+			}
+			sequence = page.sequence + 1
+		}
+
+		for {
+			page, err := readOggPage(reader)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if page.serial == first.serial {
+				page.sequence = sequence
+				sequence++
+			}
+			if _, err := writer.Write(page.marshal()); err != nil {
+				return err
+			}
+		}
+	}()
+	if writeErr == nil {
+		writeErr = writer.Flush()
+	}
+	if closeErr := out.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write Ogg file: %w", writeErr)
+	}
+
+	in.Close()
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to replace original file: %w", err)
+	}
+	return nil
+}
+
+func vorbisPictureValue(picture artworkPicture) (string, error) {
+	data, err := os.ReadFile(picture.Path)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := flacpicture.NewFromImageData(picture.flacType(), picture.description(), data, artworkMimeType(data))
+	if err != nil {
+		return "", err
+	}
+	marshaled := block.Marshal()
+	return base64.StdEncoding.EncodeToString(marshaled.Data), nil
+}
+
+func removeVorbisComments(cmt *flacvorbis.MetaDataBlockVorbisComment, keep func(key, value string) bool) {
+	kept := cmt.Comments[:0]
+	for _, comment := range cmt.Comments {
+		key, value, _ := strings.Cut(comment, "=")
+		if keep(strings.ToUpper(key), value) {
+			kept = append(kept, comment)
+		}
+	}
+	cmt.Comments = kept
+}
+
+func addVorbisPictures(cmt *flacvorbis.MetaDataBlockVorbisComment, pictures []artworkPicture) {
+	for _, picture := range pictures {
+		value, err := vorbisPictureValue(picture)
+		if err != nil {
+			fmt.Printf("Warning: Failed to create %s picture: %v\n", picture.Kind, err)
+			continue
+		}
+
+		removeVorbisComments(cmt, func(key, existing string) bool {
+			if key != vorbisPictureComment {
+				return true
+			}
+			data, err := base64.StdEncoding.DecodeString(existing)
+			if err != nil {
+				return false
+			}
+			parsed, err := flacpicture.ParseFromMetaDataBlock(flac.MetaDataBlock{Type: flac.Picture, Data: data})
+			return err == nil && parsed.PictureType != picture.flacType()
+		})
+		_ = cmt.Add(vorbisPictureComment, value)
+	}
+}
+
+func embedMetadataToOgg(filePath string, metadata Metadata, coverPath string) error {
+	metadata = resolveTitleVersionMetadata(metadata)
+
+	var pictures []artworkPicture
+	if coverPath != "" && fileExists(coverPath) {
+		pictures = append(pictures, artworkPicture{Kind: artworkKindFront, Path: coverPath})
+	}
+	pictures = append(pictures, collectExtraArtwork(filePath, metadata.AlbumArtist)...)
+
+	return updateOggComments(filePath, func(existing *flacvorbis.MetaDataBlockVorbisComment) *flacvorbis.MetaDataBlockVorbisComment {
+		cmt := buildVorbisComment(metadata)
+		cmt.Vendor = existing.Vendor
+		for _, comment := range existing.Comments {
+			if key, _, _ := strings.Cut(comment, "="); strings.EqualFold(key, vorbisPictureComment) {
+				cmt.Comments = append(cmt.Comments, comment)
+			}
+		}
+		addVorbisPictures(cmt, pictures)
+		return cmt
+	})
+}
+
+func embedLyricsToOgg(filePath string, lyrics string) error {
+	return updateOggComments(filePath, func(cmt *flacvorbis.MetaDataBlockVorbisComment) *flacvorbis.MetaDataBlockVorbisComment {
+		removeVorbisComments(cmt, func(key, _ string) bool {
+			return key != "LYRICS" && key != "UNSYNCEDLYRICS" && key != "SYNCEDLYRICS"
+		})
+		_ = cmt.Add("LYRICS", lyrics)
+		return cmt
+	})
+}
+
+func embedCoverToOgg(filePath string, coverPath string) error {
+	return updateOggComments(filePath, func(cmt *flacvorbis.MetaDataBlockVorbisComment) *flacvorbis.MetaDataBlockVorbisComment {
+		addVorbisPictures(cmt, []artworkPicture{{Kind: artworkKindFront, Path: coverPath}})
+		return cmt
+	})
+}
@@ -0,0 +1,318 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PipelineStageValidate = "validate"
+	PipelineStageVerify   = "verify"
+	PipelineStageTag      = "tag"
+	PipelineStageLyrics   = "lyrics"
+	PipelineStageCover    = "cover"
+	PipelineStageLoudness = "loudness"
+	PipelineStageConvert  = "convert"
+	PipelineStageMove     = "move"
+	PipelineStagePlaylist = "playlist"
+	PipelineStageHook     = "hook"
+
+	pipelineStageStatusSuccess = "success"
+	pipelineStageStatusFailed  = "failed"
+	pipelineStageStatusSkipped = "skipped"
+
+	defaultPipelineProfileName = "default"
+)
+
+type PipelineStageConfig struct {
+	Type     string               `json:"type"`
+	Disabled bool                 `json:"disabled,omitempty"`
+	Required *bool                `json:"required,omitempty"`
+	Options  PipelineStageOptions `json:"options,omitempty"`
+}
+
+type PipelineProfile struct {
+	Name   string                `json:"name"`
+	Stages []PipelineStageConfig `json:"stages"`
+}
+
+type PipelineStageResult struct {
+	Stage      string `json:"stage"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type PipelineTrack struct {
+	SpotifyID            string
+	TrackName            string
+	ArtistName           string
+	AlbumName            string
+	AlbumArtist          string
+	ReleaseDate          string
+	ISRC                 string
+	CoverURL             string
+	Copyright            string
+	Publisher            string
+	Composer             string
+	Separator            string
+	PlaylistName         string
+	OutputDir            string
+	TrackNumber          int
+	DiscNumber           int
+	TotalTracks          int
+	TotalDiscs           int
+	Duration             int
+	EmbedLyrics          bool
+	EmbedMaxQualityCover bool
+}
+
+type PipelineContext struct {
+	ItemID      string
+	FilePath    string
+	Track       PipelineTrack
+	FetchLyrics func() *LyricsResponse
+
+	lyricsOnce sync.Once
+	lyrics     *LyricsResponse
+}
+
+func (pc *PipelineContext) Lyrics() *LyricsResponse {
+	pc.lyricsOnce.Do(func() {
+		if pc.FetchLyrics != nil {
+			pc.lyrics = pc.FetchLyrics()
+		}
+	})
+	return pc.lyrics
+}
+
+type PipelineStageOptions map[string]interface{}
+
+func (o PipelineStageOptions) String(key, fallback string) string {
+	if value, ok := o[key].(string); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return fallback
+}
+
+func (o PipelineStageOptions) Bool(key string, fallback bool) bool {
+	if value, ok := o[key].(bool); ok {
+		return value
+	}
+	return fallback
+}
+
+func (o PipelineStageOptions) Float(key string, fallback float64) float64 {
+	if value, ok := o[key].(float64); ok {
+		return value
+	}
+	return fallback
+}
+
+func (o PipelineStageOptions) Strings(key string) []string {
+	switch value := o[key].(type) {
+	case []string:
+		return append([]string(nil), value...)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+type PipelineStageFunc func(pc *PipelineContext, options PipelineStageOptions) (string, error)
+
+type PipelineStageDefinition struct {
+	Run             PipelineStageFunc
+	Required        bool
+	RemoveOnFailure bool
+}
+
+type pipelineStageSkip struct {
+	reason string
+}
+
+func (s pipelineStageSkip) Error() string {
+	return s.reason
+}
+
+func skipPipelineStage(format string, args ...interface{}) error {
+	return pipelineStageSkip{reason: fmt.Sprintf(format, args...)}
+}
+
+type PipelineStageError struct {
+	Stage      string
+	Message    string
+	RemoveFile bool
+}
+
+func (e *PipelineStageError) Error() string {
+	return fmt.Sprintf("%s stage failed: %s", e.Stage, e.Message)
+}
+
+var (
+	pipelineStageRegistryMu sync.RWMutex
+	pipelineStageRegistry   = map[string]PipelineStageDefinition{}
+)
+
+func RegisterPipelineStage(name string, def PipelineStageDefinition) {
+	pipelineStageRegistryMu.Lock()
+	defer pipelineStageRegistryMu.Unlock()
+	pipelineStageRegistry[strings.ToLower(strings.TrimSpace(name))] = def
+}
+
+func lookupPipelineStage(name string) (PipelineStageDefinition, bool) {
+	pipelineStageRegistryMu.RLock()
+	defer pipelineStageRegistryMu.RUnlock()
+	def, ok := pipelineStageRegistry[strings.ToLower(strings.TrimSpace(name))]
+	return def, ok
+}
+
+func GetPipelineStageNames() []string {
+	pipelineStageRegistryMu.RLock()
+	defer pipelineStageRegistryMu.RUnlock()
+
+	names := make([]string, 0, len(pipelineStageRegistry))
+	for name := range pipelineStageRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func defaultPipelineProfile() PipelineProfile {
+	return PipelineProfile{
+		Name: defaultPipelineProfileName,
+		Stages: []PipelineStageConfig{
+			{Type: PipelineStageValidate},
+			{Type: PipelineStageLyrics},
+			{Type: PipelineStageCover},
+		},
+	}
+}
+
+func (p PipelineProfile) RelocatesFile() bool {
+	for _, stage := range p.Stages {
+		if !stage.Disabled && (stage.Type == PipelineStageConvert || stage.Type == PipelineStageMove) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizePipelineProfilesValue(value interface{}) []PipelineProfile {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var raw []PipelineProfile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+
+	profiles := make([]PipelineProfile, 0, len(raw))
+	seen := map[string]bool{}
+	for _, profile := range raw {
+		profile.Name = strings.TrimSpace(profile.Name)
+		key := strings.ToLower(profile.Name)
+		if profile.Name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		stages := make([]PipelineStageConfig, 0, len(profile.Stages))
+		for _, stage := range profile.Stages {
+			stage.Type = strings.ToLower(strings.TrimSpace(stage.Type))
+			if stage.Type != "" {
+				stages = append(stages, stage)
+			}
+		}
+		profile.Stages = stages
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+func ResolvePipelineProfile(name string) PipelineProfile {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = GetPipelineProfileSetting()
+	}
+
+	for _, profile := range GetPipelineProfilesSetting() {
+		if strings.EqualFold(profile.Name, name) {
+			return profile
+		}
+	}
+	if name != "" && !strings.EqualFold(name, defaultPipelineProfileName) {
+		fmt.Printf("[Pipeline] Profile %q not found, using default\n", name)
+	}
+	return defaultPipelineProfile()
+}
+
+func RunDownloadPipeline(profile PipelineProfile, pc *PipelineContext) error {
+	for _, cfg := range profile.Stages {
+		if cfg.Disabled {
+			continue
+		}
+
+		start := time.Now()
+		result := PipelineStageResult{Stage: cfg.Type}
+
+		def, ok := lookupPipelineStage(cfg.Type)
+		required := def.Required
+		if cfg.Required != nil {
+			required = *cfg.Required
+		}
+
+		if !ok {
+			result.Status = pipelineStageStatusFailed
+			result.Message = "unknown stage"
+		} else {
+			message, err := def.Run(pc, cfg.Options)
+			var skip pipelineStageSkip
+			switch {
+			case errors.As(err, &skip):
+				result.Status = pipelineStageStatusSkipped
+				result.Message = skip.reason
+			case err != nil:
+				result.Status = pipelineStageStatusFailed
+				result.Message = err.Error()
+			default:
+				result.Status = pipelineStageStatusSuccess
+				result.Message = message
+			}
+		}
+		result.DurationMs = time.Since(start).Milliseconds()
+
+		RecordDownloadItemStage(pc.ItemID, result)
+		if result.Message != "" {
+			fmt.Printf("[Pipeline] %s: %s (%s)\n", cfg.Type, result.Status, result.Message)
+		} else {
+			fmt.Printf("[Pipeline] %s: %s\n", cfg.Type, result.Status)
+		}
+
+		if result.Status == pipelineStageStatusFailed && required {
+			return &PipelineStageError{
+				Stage:      cfg.Type,
+				Message:    result.Message,
+				RemoveFile: def.RemoveOnFailure,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,581 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+const (
+	defaultMoveTemplate       = "{album_artist}/{album}"
+	defaultReplayGainLUFS     = -18.0
+	defaultHookTimeoutSeconds = 60
+	maxHookOutputLength       = 300
+)
+
+var playlistStageLock sync.Mutex
+
+func init() {
+	RegisterPipelineStage(PipelineStageValidate, PipelineStageDefinition{Run: runValidateStage, Required: true, RemoveOnFailure: true})
+	RegisterPipelineStage(PipelineStageVerify, PipelineStageDefinition{Run: runVerifyStage, Required: true, RemoveOnFailure: true})
+	RegisterPipelineStage(PipelineStageTag, PipelineStageDefinition{Run: runTagStage})
+	RegisterPipelineStage(PipelineStageLyrics, PipelineStageDefinition{Run: runLyricsStage})
+	RegisterPipelineStage(PipelineStageCover, PipelineStageDefinition{Run: runCoverStage})
+	RegisterPipelineStage(PipelineStageLoudness, PipelineStageDefinition{Run: runLoudnessStage})
+	RegisterPipelineStage(PipelineStageConvert, PipelineStageDefinition{Run: runConvertStage})
+	RegisterPipelineStage(PipelineStageMove, PipelineStageDefinition{Run: runMoveStage})
+	RegisterPipelineStage(PipelineStagePlaylist, PipelineStageDefinition{Run: runPlaylistStage})
+	RegisterPipelineStage(PipelineStageHook, PipelineStageDefinition{Run: runHookStage})
+}
+
+func (t PipelineTrack) placeholders(filePath string) map[string]string {
+	albumArtist := t.AlbumArtist
+	if albumArtist == "" {
+		albumArtist = t.ArtistName
+	}
+	return map[string]string{
+		"{title}":        t.TrackName,
+		"{artist}":       t.ArtistName,
+		"{album}":        t.AlbumName,
+		"{album_artist}": albumArtist,
+		"{year}":         extractYear(t.ReleaseDate),
+		"{isrc}":         t.ISRC,
+		"{playlist}":     t.PlaylistName,
+		"{spotify_id}":   t.SpotifyID,
+		"{track}":        fmt.Sprintf("%02d", t.TrackNumber),
+		"{disc}":         strconv.Itoa(t.DiscNumber),
+		"{file}":         filePath,
+	}
+}
+
+func expandPipelineTemplate(template string, values map[string]string, sanitize bool) string {
+	for key, value := range values {
+		if sanitize && value != "" {
+			value = SanitizeFilename(value)
+		}
+		template = strings.ReplaceAll(template, key, value)
+	}
+	return template
+}
+
+func runValidateStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	validated, err := ValidateDownloadedTrackDuration(pc.FilePath, pc.Track.Duration)
+	if err != nil {
+		return "", err
+	}
+	if !validated {
+		return "", skipPipelineStage("duration not available (expected=%ds)", pc.Track.Duration)
+	}
+	return "duration matches", nil
+}
+
+func runVerifyStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	info, err := readNativeAudioInfo(pc.FilePath)
+	if err != nil {
+		meta, probeErr := GetMetadataWithFFprobe(pc.FilePath)
+		if probeErr != nil {
+			return "", fmt.Errorf("unreadable audio stream: %w", probeErr)
+		}
+		info = &nativeAudioInfo{SampleRate: meta.SampleRate, BitsPerSample: meta.BitsPerSample, Duration: meta.Duration}
+	}
+	if info.SampleRate == 0 || info.Duration <= 0 {
+		return "", fmt.Errorf("no playable audio stream found")
+	}
+
+	if info.MD5 == "" || !options.Bool("decode", true) {
+		return "stream headers ok", nil
+	}
+
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return "stream headers ok, ffmpeg unavailable for decode check", nil
+	}
+	decoded, err := decodedAudioMD5(context.Background(), ffmpegPath, pc.FilePath, int(info.BitsPerSample))
+	if err != nil {
+		return "", err
+	}
+	if decoded != info.MD5 {
+		return "", fmt.Errorf("decoded audio does not match the STREAMINFO MD5")
+	}
+	return "audio MD5 verified", nil
+}
+
+func runTagStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	overwrite := options.Bool("overwrite", false)
+
+	metadata, err := ExtractFullMetadataFromFile(pc.FilePath)
+	if err != nil && !overwrite {
+		return "", fmt.Errorf("failed to read existing tags: %w", err)
+	}
+	if lyrics, err := ExtractLyrics(pc.FilePath); err == nil {
+		metadata.Lyrics = lyrics
+	}
+
+	track := pc.Track
+	url := ""
+	if track.SpotifyID != "" {
+		url = fmt.Sprintf("https://open.spotify.com/track/%s", track.SpotifyID)
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&metadata.Title, track.TrackName},
+		{&metadata.Artist, track.ArtistName},
+		{&metadata.Album, track.AlbumName},
+		{&metadata.AlbumArtist, track.AlbumArtist},
+		{&metadata.Date, track.ReleaseDate},
+		{&metadata.ReleaseDate, track.ReleaseDate},
+		{&metadata.ISRC, track.ISRC},
+		{&metadata.Copyright, track.Copyright},
+		{&metadata.Publisher, track.Publisher},
+		{&metadata.Composer, track.Composer},
+		{&metadata.URL, url},
+		{&metadata.Separator, track.Separator},
+	} {
+		if field.src != "" && (overwrite || *field.dst == "") {
+			*field.dst = field.src
+		}
+	}
+	for _, field := range []struct {
+		dst *int
+		src int
+	}{
+		{&metadata.TrackNumber, track.TrackNumber},
+		{&metadata.TotalTracks, track.TotalTracks},
+		{&metadata.DiscNumber, track.DiscNumber},
+		{&metadata.TotalDiscs, track.TotalDiscs},
+	} {
+		if field.src > 0 && (overwrite || *field.dst == 0) {
+			*field.dst = field.src
+		}
+	}
+
+	if err := EmbedMetadataToConvertedFile(pc.FilePath, metadata, ""); err != nil {
+		return "", err
+	}
+	return "tags written", nil
+}
+
+func runLyricsStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	if !pc.Track.EmbedLyrics || pc.Track.SpotifyID == "" {
+		return "", skipPipelineStage("lyrics not requested")
+	}
+	switch strings.ToLower(filepath.Ext(pc.FilePath)) {
+	case ".flac", ".mp3", ".m4a":
+	default:
+		return "", skipPipelineStage("unsupported format for lyrics")
+	}
+
+	fmt.Printf("\nWaiting for lyrics fetch to complete...\n")
+	lyrics := pc.Lyrics()
+	if lyrics == nil {
+		fmt.Println("No lyrics found to embed.")
+		return "", skipPipelineStage("no lyrics found")
+	}
+
+	client := NewLyricsClient()
+	fmt.Printf("\n--- Full LRC Content ---\n")
+	fmt.Println(client.FormatEmbeddedLyrics(lyrics, pc.Track.TrackName, pc.Track.ArtistName))
+	fmt.Printf("--- End LRC Content ---\n\n")
+
+	if err := client.SaveLyricsForAudioFile(pc.FilePath, lyrics, pc.Track.TrackName, pc.Track.ArtistName); err != nil {
+		return "", fmt.Errorf("failed to save lyrics: %w", err)
+	}
+	fmt.Printf("Lyrics saved successfully!\n")
+	return "lyrics saved", nil
+}
+
+func runCoverStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	if pc.Track.CoverURL == "" {
+		return "", skipPipelineStage("no cover URL")
+	}
+
+	var done []string
+	coverClient := NewCoverClient()
+
+	if options.Bool("embed_if_missing", false) {
+		existing, err := ExtractCoverArt(pc.FilePath)
+		if existing != "" {
+			os.Remove(existing)
+		}
+		if err != nil || existing == "" {
+			coverPath := pc.FilePath + ".cover.jpg"
+			defer os.Remove(coverPath)
+			if err := coverClient.DownloadCoverToPath(pc.Track.CoverURL, coverPath, pc.Track.EmbedMaxQualityCover); err != nil {
+				return "", fmt.Errorf("failed to download cover: %w", err)
+			}
+			if err := EmbedCoverArtOnly(pc.FilePath, coverPath); err != nil {
+				return "", fmt.Errorf("failed to embed cover: %w", err)
+			}
+			done = append(done, "cover embedded")
+		}
+	}
+
+	if runtime.GOOS == "darwin" && strings.EqualFold(filepath.Ext(pc.FilePath), ".flac") {
+		if err := coverClient.ApplyMacOSFLACFileIcon(pc.FilePath, pc.Track.CoverURL, 256, pc.Track.EmbedMaxQualityCover); err != nil {
+			fmt.Printf("Warning: failed to set macOS FLAC file icon: %v\n", err)
+		} else {
+			fmt.Printf("macOS FLAC file icon set: %s\n", pc.FilePath)
+			done = append(done, "file icon set")
+		}
+	}
+
+	if len(done) == 0 {
+		return "", skipPipelineStage("nothing to do")
+	}
+	return strings.Join(done, ", "), nil
+}
+
+func parseEBUR128Summary(output string) (float64, float64, bool) {
+	integrated, peak := math.NaN(), math.NaN()
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case fields[0] == "I:" && fields[2] == "LUFS":
+			integrated = value
+		case fields[0] == "Peak:" && fields[2] == "dBFS":
+			peak = value
+		}
+	}
+	return integrated, peak, !math.IsNaN(integrated) && !math.IsNaN(peak)
+}
+
+func writeReplayGainTags(filePath, gain, peak string) error {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".flac":
+		f, err := flac.ParseFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse FLAC file: %w", err)
+		}
+
+		cmtIdx := -1
+		cmt := flacvorbis.New()
+		for idx, block := range f.Meta {
+			if block.Type == flac.VorbisComment {
+				cmtIdx = idx
+				if existing, err := flacvorbis.ParseFromMetaDataBlock(*block); err == nil {
+					cmt = existing
+				}
+				break
+			}
+		}
+		removeVorbisComments(cmt, func(key, value string) bool {
+			return key != "REPLAYGAIN_TRACK_GAIN" && key != "REPLAYGAIN_TRACK_PEAK"
+		})
+		_ = cmt.Add("REPLAYGAIN_TRACK_GAIN", gain)
+		_ = cmt.Add("REPLAYGAIN_TRACK_PEAK", peak)
+
+		cmtBlock := cmt.Marshal()
+		if cmtIdx < 0 {
+			f.Meta = append(f.Meta, &cmtBlock)
+		} else {
+			f.Meta[cmtIdx] = &cmtBlock
+		}
+		if err := f.Save(filePath); err != nil {
+			return fmt.Errorf("failed to save FLAC file: %w", err)
+		}
+		return nil
+	case ".mp3":
+		tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+		if err != nil {
+			return fmt.Errorf("failed to open MP3 file: %w", err)
+		}
+		defer tag.Close()
+
+		frames := tag.GetFrames("TXXX")
+		tag.DeleteFrames("TXXX")
+		for _, frame := range frames {
+			udf, ok := frame.(id3v2.UserDefinedTextFrame)
+			if ok && (strings.EqualFold(udf.Description, "REPLAYGAIN_TRACK_GAIN") || strings.EqualFold(udf.Description, "REPLAYGAIN_TRACK_PEAK")) {
+				continue
+			}
+			tag.AddFrame("TXXX", frame)
+		}
+		for description, value := range map[string]string{"REPLAYGAIN_TRACK_GAIN": gain, "REPLAYGAIN_TRACK_PEAK": peak} {
+			tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+				Encoding:    id3v2.EncodingUTF8,
+				Description: description,
+				Value:       value,
+			})
+		}
+		if err := tag.Save(); err != nil {
+			return fmt.Errorf("failed to save MP3 tags: %w", err)
+		}
+		return nil
+	case ".m4a":
+		return updateMP4Tags(filePath, func(tags *mp4Tags) {
+			tags.setFreeform("replaygain_track_gain", gain)
+			tags.setFreeform("replaygain_track_peak", peak)
+		})
+	case ".opus", ".ogg":
+		return updateOggComments(filePath, func(cmt *flacvorbis.MetaDataBlockVorbisComment) *flacvorbis.MetaDataBlockVorbisComment {
+			removeVorbisComments(cmt, func(key, value string) bool {
+				return key != "REPLAYGAIN_TRACK_GAIN" && key != "REPLAYGAIN_TRACK_PEAK"
+			})
+			_ = cmt.Add("REPLAYGAIN_TRACK_GAIN", gain)
+			_ = cmt.Add("REPLAYGAIN_TRACK_PEAK", peak)
+			return cmt
+		})
+	default:
+		return skipPipelineStage("unsupported format for ReplayGain tags")
+	}
+}
+
+func runLoudnessStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get ffmpeg path: %w", err)
+	}
+
+	args := []string{"-hide_banner", "-i", pc.FilePath, "-map", "0:a", "-af", "ebur128=peak=true", "-f", "null", "-"}
+	duration, _ := GetAudioDuration(pc.FilePath)
+	output, err := runFFmpegWithProgress(context.Background(), ffmpegPath, args, duration, nil)
+	if err != nil {
+		return "", fmt.Errorf("loudness analysis failed: %w", err)
+	}
+
+	integrated, peakDB, ok := parseEBUR128Summary(string(output))
+	if !ok {
+		return "", fmt.Errorf("could not parse loudness analysis output")
+	}
+
+	reference := options.Float("reference_lufs", defaultReplayGainLUFS)
+	gain := fmt.Sprintf("%.2f dB", reference-integrated)
+	peak := fmt.Sprintf("%.6f", math.Pow(10, peakDB/20))
+	if err := writeReplayGainTags(pc.FilePath, gain, peak); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.1f LUFS, track gain %s", integrated, gain), nil
+}
+
+func runConvertStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	presetName := options.String("preset", "")
+	if presetName == "" {
+		return "", fmt.Errorf("no conversion preset configured")
+	}
+	preset, ok := FindConversionPreset(presetName)
+	if !ok {
+		return "", fmt.Errorf("conversion preset %q not found", presetName)
+	}
+
+	inputExt := strings.ToLower(filepath.Ext(pc.FilePath))
+	outputExt := "." + preset.Format
+	if inputExt == outputExt {
+		return "", skipPipelineStage("already %s", preset.Format)
+	}
+
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get ffmpeg path: %w", err)
+	}
+	if err := ValidateExecutable(ffmpegPath); err != nil {
+		return "", fmt.Errorf("invalid ffmpeg executable: %w", err)
+	}
+
+	outputFile := strings.TrimSuffix(pc.FilePath, filepath.Ext(pc.FilePath)) + outputExt
+	if err := convertAudioFile(context.Background(), ffmpegPath, pc.FilePath, outputFile, preset, nil); err != nil {
+		os.Remove(outputFile)
+		return "", err
+	}
+
+	if !options.Bool("keep_source", false) {
+		if err := os.Remove(pc.FilePath); err != nil {
+			fmt.Printf("[Pipeline] Warning: failed to remove source %s: %v\n", pc.FilePath, err)
+		}
+	}
+	pc.FilePath = outputFile
+	return fmt.Sprintf("converted with %s", preset.Name), nil
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyMirrorFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+func runMoveStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	root := options.String("dir", pc.Track.OutputDir)
+	if root == "" {
+		root = filepath.Dir(pc.FilePath)
+	}
+
+	template := options.String("template", defaultMoveTemplate)
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(template), "/") {
+		if part = strings.TrimSpace(expandPipelineTemplate(part, pc.Track.placeholders(""), true)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	targetDir := SanitizeFolderPath(filepath.Join(append([]string{NormalizePath(root)}, parts...)...))
+	target := filepath.Join(targetDir, filepath.Base(pc.FilePath))
+
+	if filepath.Clean(target) == filepath.Clean(pc.FilePath) {
+		return "", skipPipelineStage("already in place")
+	}
+	if _, err := os.Stat(target); err == nil && !options.Bool("overwrite", false) {
+		return "", fmt.Errorf("target already exists: %s", target)
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create target directory: %w", err)
+	}
+	if err := moveFile(pc.FilePath, target); err != nil {
+		return "", fmt.Errorf("failed to move file: %w", err)
+	}
+
+	sourceBase := strings.TrimSuffix(pc.FilePath, filepath.Ext(pc.FilePath))
+	targetBase := strings.TrimSuffix(target, filepath.Ext(target))
+	for _, ext := range []string{".lrc", ".ttml"} {
+		if _, err := os.Stat(sourceBase + ext); err == nil {
+			if err := moveFile(sourceBase+ext, targetBase+ext); err != nil {
+				fmt.Printf("[Pipeline] Warning: failed to move %s sidecar: %v\n", ext, err)
+			}
+		}
+	}
+	for _, name := range coverSidecarNames {
+		src := filepath.Join(filepath.Dir(pc.FilePath), name)
+		dst := filepath.Join(targetDir, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := copyMirrorFile(src, dst); err != nil {
+			fmt.Printf("[Pipeline] Warning: failed to copy %s: %v\n", name, err)
+		}
+	}
+
+	pc.FilePath = target
+	return target, nil
+}
+
+func runPlaylistStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	playlistPath := options.String("path", "")
+	if playlistPath == "" {
+		if pc.Track.PlaylistName == "" {
+			return "", skipPipelineStage("not a playlist download")
+		}
+		playlistPath = filepath.Join(pc.Track.OutputDir, SanitizeFilename(pc.Track.PlaylistName)+".m3u8")
+	} else {
+		playlistPath = expandPipelineTemplate(playlistPath, pc.Track.placeholders(""), false)
+		if !filepath.IsAbs(playlistPath) {
+			playlistPath = filepath.Join(pc.Track.OutputDir, playlistPath)
+		}
+	}
+
+	entry := pc.FilePath
+	if rel, err := filepath.Rel(filepath.Dir(playlistPath), pc.FilePath); err == nil && !strings.HasPrefix(rel, "..") {
+		entry = filepath.ToSlash(rel)
+	}
+
+	playlistStageLock.Lock()
+	defer playlistStageLock.Unlock()
+
+	existing, err := os.ReadFile(playlistPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == entry {
+			return "", skipPipelineStage("already listed")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(playlistPath), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(playlistPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open playlist: %w", err)
+	}
+	defer f.Close()
+
+	var lines strings.Builder
+	if len(existing) == 0 {
+		lines.WriteString("#EXTM3U\n")
+	} else if existing[len(existing)-1] != '\n' {
+		lines.WriteString("\n")
+	}
+	duration := pc.Track.Duration
+	if duration <= 0 {
+		duration = -1
+	}
+	fmt.Fprintf(&lines, "#EXTINF:%d,%s - %s\n%s\n", duration, pc.Track.ArtistName, pc.Track.TrackName, entry)
+	if _, err := f.WriteString(lines.String()); err != nil {
+		return "", fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return playlistPath, nil
+}
+
+func runHookStage(pc *PipelineContext, options PipelineStageOptions) (string, error) {
+	command := options.String("command", "")
+	if command == "" {
+		return "", fmt.Errorf("no hook command configured")
+	}
+
+	values := pc.Track.placeholders(pc.FilePath)
+	args := options.Strings("args")
+	for i, arg := range args {
+		args[i] = expandPipelineTemplate(arg, values, false)
+	}
+
+	timeout := time.Duration(options.Float("timeout", defaultHookTimeoutSeconds)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	setHideWindow(cmd)
+	cmd.Env = append(os.Environ(),
+		"SPOTIFLAC_ITEM_ID="+pc.ItemID,
+		"SPOTIFLAC_FILE="+pc.FilePath,
+		"SPOTIFLAC_TITLE="+pc.Track.TrackName,
+		"SPOTIFLAC_ARTIST="+pc.Track.ArtistName,
+		"SPOTIFLAC_ALBUM="+pc.Track.AlbumName,
+		"SPOTIFLAC_ALBUM_ARTIST="+pc.Track.AlbumArtist,
+		"SPOTIFLAC_ISRC="+pc.Track.ISRC,
+		"SPOTIFLAC_SPOTIFY_ID="+pc.Track.SpotifyID,
+		"SPOTIFLAC_PLAYLIST="+pc.Track.PlaylistName,
+	)
+
+	output, err := cmd.CombinedOutput()
+	trimmed := strings.TrimSpace(string(output))
+	if len(trimmed) > maxHookOutputLength {
+		trimmed = trimmed[len(trimmed)-maxHookOutputLength:]
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("hook timed out after %s", timeout)
+	}
+	if err != nil {
+		if trimmed != "" {
+			return "", fmt.Errorf("%v: %s", err, trimmed)
+		}
+		return "", err
+	}
+	return trimmed, nil
+}
@@ -18,19 +18,20 @@ const (
 )
 
 type DownloadItem struct {
-	ID           string         `json:"id"`
-	TrackName    string         `json:"track_name"`
-	ArtistName   string         `json:"artist_name"`
-	AlbumName    string         `json:"album_name"`
-	SpotifyID    string         `json:"spotify_id"`
-	Status       DownloadStatus `json:"status"`
-	Progress     float64        `json:"progress"`
-	TotalSize    float64        `json:"total_size"`
-	Speed        float64        `json:"speed"`
-	StartTime    int64          `json:"start_time"`
-	EndTime      int64          `json:"end_time"`
-	ErrorMessage string         `json:"error_message"`
-	FilePath     string         `json:"file_path"`
+	ID           string                `json:"id"`
+	TrackName    string                `json:"track_name"`
+	ArtistName   string                `json:"artist_name"`
+	AlbumName    string                `json:"album_name"`
+	SpotifyID    string                `json:"spotify_id"`
+	Status       DownloadStatus        `json:"status"`
+	Progress     float64               `json:"progress"`
+	TotalSize    float64               `json:"total_size"`
+	Speed        float64               `json:"speed"`
+	StartTime    int64                 `json:"start_time"`
+	EndTime      int64                 `json:"end_time"`
+	ErrorMessage string                `json:"error_message"`
+	FilePath     string                `json:"file_path"`
+	Stages       []PipelineStageResult `json:"stages,omitempty"`
 }
 
 var (
@@ -220,6 +221,7 @@ func StartDownloadItem(id string) {
 			downloadQueue[i].Status = StatusDownloading
 			downloadQueue[i].StartTime = time.Now().Unix()
 			downloadQueue[i].Progress = 0
+			downloadQueue[i].Stages = nil
 			break
 		}
 	}
@@ -242,6 +244,18 @@ func UpdateItemProgress(id string, progress, speed float64) {
 	}
 }
 
+func RecordDownloadItemStage(id string, result PipelineStageResult) {
+	downloadQueueLock.Lock()
+	defer downloadQueueLock.Unlock()
+
+	for i := range downloadQueue {
+		if downloadQueue[i].ID == id {
+			downloadQueue[i].Stages = append(downloadQueue[i].Stages, result)
+			break
+		}
+	}
+}
+
 func GetCurrentItemID() string {
 	currentItemLock.RLock()
 	defer currentItemLock.RUnlock()
@@ -39,6 +39,7 @@ type QobuzTrack struct {
 	Hires               bool    `json:"hires"`
 	HiresStreamable     bool    `json:"hires_streamable"`
 	ReleaseDateOriginal string  `json:"release_date_original"`
+	ParentalWarning     bool    `json:"parental_warning"`
 	Performer           struct {
 		Name string `json:"name"`
 		ID   int64  `json:"id"`
@@ -58,6 +59,10 @@ type QobuzTrack struct {
 		Label struct {
 			Name string `json:"name"`
 		} `json:"label"`
+		Genre struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"genre"`
 	} `json:"album"`
 }
 
@@ -217,8 +222,14 @@ func qobuzTrackSupportsHiRes(track QobuzTrack) bool {
 func scoreQobuzSearchCandidate(track QobuzTrack, spotifyTrackName string, spotifyArtistName string, spotifyAlbumName string) int {
 	score := 0
 
-	titleNeedle := normalizeQobuzSearchValue(spotifyTrackName)
-	titleHaystack := normalizeQobuzSearchValue(track.Title)
+	spotifyTitle := ParseTitleVersion(spotifyTrackName)
+	candidateTitle := ParseTitleVersion(track.Title)
+	if version := strings.TrimSpace(track.Version); version != "" {
+		candidateTitle = ParseTitleVersion(candidateTitle.Title + " (" + version + ")")
+	}
+
+	titleNeedle := normalizeQobuzSearchValue(spotifyTitle.Title)
+	titleHaystack := normalizeQobuzSearchValue(candidateTitle.Title)
 	switch {
 	case titleNeedle != "" && titleHaystack == titleNeedle:
 		score += 1000
@@ -226,6 +237,14 @@ func scoreQobuzSearchCandidate(track QobuzTrack, spotifyTrackName string, spotif
 		score += 500
 	}
 
+	if sameTitleVersionKinds(spotifyTitle.SignificantKinds(), candidateTitle.SignificantKinds()) {
+		if normalizeQobuzSearchValue(spotifyTitle.Version()) == normalizeQobuzSearchValue(candidateTitle.Version()) {
+			score += 60
+		}
+	} else {
+		score -= 200
+	}
+
 	artistNeedle := normalizeQobuzSearchValue(spotifyArtistName)
 	artistHaystack := normalizeQobuzSearchValue(qobuzTrackDisplayArtist(track))
 	switch {
@@ -244,6 +263,8 @@ func scoreQobuzSearchCandidate(track QobuzTrack, spotifyTrackName string, spotif
 		score += 90
 	}
 
+	score += scoreExplicitPreference(track.ParentalWarning, candidateTitle, spotifyTitle)
+
 	if qobuzTrackSupportsHiRes(track) {
 		score += 40
 	} else if track.MaximumBitDepth >= 16 {
@@ -1044,8 +1065,8 @@ func (q *QobuzDownloader) DownloadTrackWithISRC(isrc, outputDir, quality, filena
 		safeAlbumArtist = sanitizeFilename(GetFirstArtist(spotifyAlbumArtist))
 	}
 
-	safeTitle := sanitizeFilename(trackTitle)
-	safeAlbum := sanitizeFilename(albumTitle)
+	safeTitle := sanitizeFilename(ResolveFilenameTitle(trackTitle))
+	safeAlbum := sanitizeFilename(ResolveFilenameTitle(albumTitle))
 
 	filename := buildQobuzFilename(safeTitle, safeArtist, safeAlbum, safeAlbumArtist, spotifyReleaseDate, spotifyTrackNumber, spotifyDiscNumber, filenameFormat, includeTrackNumber, position, useAlbumTrackNumber, isrc)
 	filepath := filepath.Join(outputDir, filename)
@@ -1062,19 +1083,13 @@ func (q *QobuzDownloader) DownloadTrackWithISRC(isrc, outputDir, quality, filena
 
 	fmt.Printf("Downloaded: %s\n", filepath)
 
-	coverPath := ""
-
-	if spotifyCoverURL != "" {
-		coverPath = filepath + ".cover.jpg"
-		coverClient := NewCoverClient()
-		if err := coverClient.DownloadCoverToPath(spotifyCoverURL, coverPath, embedMaxQualityCover); err != nil {
-			fmt.Printf("Warning: Failed to download Spotify cover: %v\n", err)
-			coverPath = ""
-		} else {
-			defer os.Remove(coverPath)
-			fmt.Println("Spotify cover downloaded")
-		}
-	}
+	coverPath, cleanupCover := prepareTrackArtwork(filepath, spotifyCoverURL, embedMaxQualityCover, CoverLookup{
+		SpotifyURL: spotifyURL,
+		TrackName:  spotifyTrackName,
+		ArtistName: spotifyArtistName,
+		AlbumName:  spotifyAlbumName,
+	})
+	defer cleanupCover()
 
 	var mbMeta Metadata
 	if isrc != "" {
@@ -1115,7 +1130,8 @@ func (q *QobuzDownloader) DownloadTrackWithISRC(isrc, outputDir, quality, filena
 		Description: "https://github.com/spotbye/SpotiFLAC",
 		ISRC:        isrc,
 		UPC:         upc,
-		Genre:       mbMeta.Genre,
+		Genre:       ResolveGenreFromSources(GenreSourceInput{MusicBrainz: mbMeta.Genre, SpotifyTrackID: spotifyURL, Qobuz: track.Album.Genre.Name}, useSingleGenre, embedGenre),
+		Advisory:    ResolveContentAdvisory(spotifyURL, trackTitle, track.ParentalWarning),
 	}
 
 	if err := EmbedMetadata(filepath, metadata, coverPath); err != nil {
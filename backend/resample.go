@@ -1,13 +1,17 @@
 package backend
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
-	"sync"
+
+	"github.com/go-flac/go-flac"
 )
 
 type FlacInfo struct {
@@ -16,79 +20,124 @@ type FlacInfo struct {
 	BitsPerSample uint8  `json:"bits_per_sample"`
 }
 
-func GetFlacInfoBatch(paths []string) []FlacInfo {
-	results := make([]FlacInfo, len(paths))
-	var wg sync.WaitGroup
-
-	for i, path := range paths {
-		wg.Add(1)
-		go func(idx int, p string) {
-			defer wg.Done()
-			info := FlacInfo{Path: p}
+func probeAudioInfo(ctx context.Context, p string) FlacInfo {
+	info := FlacInfo{Path: p}
 
-			ffprobePath, err := GetFFprobePath()
-			if err != nil {
-				results[idx] = info
-				return
-			}
+	if native, err := readNativeAudioInfo(p); err == nil && native.SampleRate > 0 {
+		info.SampleRate = native.SampleRate
+		info.BitsPerSample = native.BitsPerSample
+		return info
+	}
 
-			args := []string{
-				"-v", "error",
-				"-select_streams", "a:0",
-				"-show_entries", "stream=sample_rate,bits_per_raw_sample,bits_per_sample",
-				"-of", "default=noprint_wrappers=0",
-				p,
-			}
-			cmd := exec.Command(ffprobePath, args...)
-			setHideWindow(cmd)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				results[idx] = info
-				return
-			}
+	ffprobePath, err := GetFFprobePath()
+	if err != nil {
+		return info
+	}
 
-			kvMap := make(map[string]string)
-			for _, line := range strings.Split(string(out), "\n") {
-				if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
-					kvMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-				}
-			}
+	args := []string{
+		"-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "stream=sample_rate,bits_per_raw_sample,bits_per_sample",
+		"-of", "default=noprint_wrappers=0",
+		p,
+	}
+	cmd := exec.CommandContext(ctx, ffprobePath, args...)
+	setHideWindow(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return info
+	}
 
-			if v, ok := kvMap["sample_rate"]; ok {
-				if s, err := strconv.Atoi(v); err == nil {
-					info.SampleRate = uint32(s)
-				}
-			}
+	kvMap := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+			kvMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
 
-			bits := 0
-			if v, ok := kvMap["bits_per_raw_sample"]; ok && v != "N/A" && v != "" {
-				bits, _ = strconv.Atoi(v)
-			}
-			if bits == 0 {
-				if v, ok := kvMap["bits_per_sample"]; ok && v != "N/A" && v != "" {
-					bits, _ = strconv.Atoi(v)
-				}
-			}
-			info.BitsPerSample = uint8(bits)
+	if v, ok := kvMap["sample_rate"]; ok {
+		if s, err := strconv.Atoi(v); err == nil {
+			info.SampleRate = uint32(s)
+		}
+	}
 
-			results[idx] = info
-		}(i, path)
+	bits := 0
+	if v, ok := kvMap["bits_per_raw_sample"]; ok && v != "N/A" && v != "" {
+		bits, _ = strconv.Atoi(v)
+	}
+	if bits == 0 {
+		if v, ok := kvMap["bits_per_sample"]; ok && v != "N/A" && v != "" {
+			bits, _ = strconv.Atoi(v)
+		}
 	}
+	info.BitsPerSample = uint8(bits)
+
+	return info
+}
+
+func GetFlacInfoBatch(paths []string) []FlacInfo {
+	results := make([]FlacInfo, len(paths))
+	job := startAudioJob("", len(paths), nil)
+	defer job.finish()
 
-	wg.Wait()
+	job.run(func(idx int) {
+		results[idx] = probeAudioInfo(job.ctx, paths[idx])
+	})
 	return results
 }
 
+const (
+	resampleOutputFolder  = "folder"
+	resampleOutputInPlace = "in_place"
+	resampleOutputMirror  = "mirror"
+
+	resamplerSWR  = "swr"
+	resamplerSOXR = "soxr"
+
+	minSoxrPrecision = 15
+	maxSoxrPrecision = 33
+)
+
+var resampleDitherMethods = map[string]bool{
+	"rectangular":   true,
+	"triangular":    true,
+	"triangular_hp": true,
+}
+
+var resampleNoiseShapingMethods = map[string]bool{
+	"lipshitz":            true,
+	"shibata":             true,
+	"low_shibata":         true,
+	"high_shibata":        true,
+	"f_weighted":          true,
+	"e_weighted":          true,
+	"modified_e_weighted": true,
+	"improved_e_weighted": true,
+}
+
 type ResampleRequest struct {
-	InputFiles []string `json:"input_files"`
-	SampleRate string   `json:"sample_rate"`
-	BitDepth   string   `json:"bit_depth"`
+	InputFiles         []string `json:"input_files"`
+	SampleRate         string   `json:"sample_rate"`
+	BitDepth           string   `json:"bit_depth"`
+	Resampler          string   `json:"resampler,omitempty"`
+	Precision          int      `json:"precision,omitempty"`
+	Dither             string   `json:"dither,omitempty"`
+	NoiseShaping       string   `json:"noise_shaping,omitempty"`
+	OutputMode         string   `json:"output_mode,omitempty"`
+	OutputRoot         string   `json:"output_root,omitempty"`
+	SourceRoot         string   `json:"source_root,omitempty"`
+	Backup             bool     `json:"backup,omitempty"`
+	OnlyDownsample     bool     `json:"only_downsample,omitempty"`
+	OnlyReduceBitDepth bool     `json:"only_reduce_bit_depth,omitempty"`
+	JobID              string   `json:"job_id,omitempty"`
 }
 
 type ResampleResult struct {
 	InputFile  string `json:"input_file"`
 	OutputFile string `json:"output_file"`
+	BackupFile string `json:"backup_file,omitempty"`
 	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped,omitempty"`
 	Error      string `json:"error,omitempty"`
 }
 
@@ -120,7 +169,115 @@ func buildFolderLabel(sampleRate, bitDepth string) string {
 	return strings.Join(parts, " ")
 }
 
-func ResampleAudio(req ResampleRequest) ([]ResampleResult, error) {
+func (req ResampleRequest) targetFor(source FlacInfo) (int, int) {
+	sampleRate, _ := strconv.Atoi(req.SampleRate)
+	bitDepth, _ := strconv.Atoi(req.BitDepth)
+
+	if req.OnlyDownsample && source.SampleRate > 0 && sampleRate >= int(source.SampleRate) {
+		sampleRate = 0
+	}
+	if req.OnlyReduceBitDepth && source.BitsPerSample > 0 && bitDepth >= int(source.BitsPerSample) {
+		bitDepth = 0
+	}
+	return sampleRate, bitDepth
+}
+
+func (req ResampleRequest) resampleFilter(sampleRate, bitDepth int, source FlacInfo) string {
+	var opts []string
+	if sampleRate > 0 {
+		opts = append(opts, strconv.Itoa(sampleRate))
+	}
+
+	if strings.ToLower(strings.TrimSpace(req.Resampler)) == resamplerSOXR {
+		opts = append(opts, "resampler="+resamplerSOXR)
+		if req.Precision > 0 {
+			opts = append(opts, "precision="+strconv.Itoa(min(max(req.Precision, minSoxrPrecision), maxSoxrPrecision)))
+		}
+	}
+
+	if bitDepth == 16 && (source.BitsPerSample == 0 || source.BitsPerSample > 16) {
+		method := strings.ToLower(strings.TrimSpace(req.NoiseShaping))
+		if !resampleNoiseShapingMethods[method] {
+			method = strings.ToLower(strings.TrimSpace(req.Dither))
+		}
+		if resampleDitherMethods[method] || resampleNoiseShapingMethods[method] {
+			opts = append(opts, "osf=s16", "dither_method="+method)
+		}
+	}
+
+	if len(opts) == 0 {
+		return ""
+	}
+	return "aresample=" + strings.Join(opts, ":")
+}
+
+func commonParentDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	common := filepath.Dir(paths[0])
+	for _, p := range paths[1:] {
+		dir := filepath.Dir(p)
+		for common != "" {
+			rel, err := filepath.Rel(common, dir)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+			parent := filepath.Dir(common)
+			if parent == common {
+				return common
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+func uniqueBackupPath(path string) string {
+	candidate := path + ".bak"
+	for i := 1; fileExists(candidate); i++ {
+		candidate = fmt.Sprintf("%s.%02d.bak", path, i)
+	}
+	return candidate
+}
+
+func copyFlacMetadataBlocks(srcPath, dstPath string, types ...flac.BlockType) error {
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	src, err := flac.ParseMetadata(bufio.NewReader(in))
+	in.Close()
+	if err != nil {
+		return fmt.Errorf("failed to parse source FLAC: %w", err)
+	}
+
+	dst, err := flac.ParseFile(dstPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse output FLAC: %w", err)
+	}
+
+	var kept []*flac.MetaDataBlock
+	for _, block := range dst.Meta {
+		if block.Type == flac.StreamInfo || block.Type == flac.SeekTable {
+			kept = append(kept, block)
+		}
+	}
+	for _, block := range src.Meta {
+		if slices.Contains(types, block.Type) {
+			kept = append(kept, block)
+		}
+	}
+	dst.Meta = kept
+
+	if err := dst.Save(dstPath); err != nil {
+		return fmt.Errorf("failed to save FLAC file: %w", err)
+	}
+	return nil
+}
+
+func ResampleAudio(req ResampleRequest, progress func(AudioJobProgress)) ([]ResampleResult, error) {
 	ffmpegPath, err := GetFFmpegPath()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ffmpeg path: %w", err)
@@ -139,85 +296,170 @@ func ResampleAudio(req ResampleRequest) ([]ResampleResult, error) {
 		return nil, fmt.Errorf("at least one of sample rate or bit depth must be specified")
 	}
 
+	outputMode := strings.ToLower(strings.TrimSpace(req.OutputMode))
+	switch outputMode {
+	case resampleOutputInPlace:
+	case resampleOutputMirror:
+		if strings.TrimSpace(req.OutputRoot) == "" {
+			return nil, fmt.Errorf("output folder is required for mirror mode")
+		}
+	default:
+		outputMode = resampleOutputFolder
+	}
+
+	sourceRoot := strings.TrimSpace(req.SourceRoot)
+	if outputMode == resampleOutputMirror && sourceRoot == "" {
+		sourceRoot = commonParentDir(req.InputFiles)
+	}
+
 	results := make([]ResampleResult, len(req.InputFiles))
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	job := startAudioJob(req.JobID, len(req.InputFiles), progress)
+	defer job.finish()
 
 	folderLabel := buildFolderLabel(req.SampleRate, req.BitDepth)
 
-	for i, inputFile := range req.InputFiles {
-		wg.Add(1)
-		go func(idx int, inputFile string) {
-			defer wg.Done()
+	job.run(func(idx int) {
+		inputFile := req.InputFiles[idx]
+		result := ResampleResult{
+			InputFile: inputFile,
+		}
+		defer func() {
+			results[idx] = result
+		}()
 
-			result := ResampleResult{
-				InputFile: inputFile,
-			}
+		if job.cancelled() {
+			result.Error = "cancelled"
+			job.report(idx, inputFile, audioJobStatusCancelled, 0)
+			return
+		}
+		job.report(idx, inputFile, audioJobStatusRunning, 0)
 
-			inputExt := strings.ToLower(filepath.Ext(inputFile))
-			baseName := strings.TrimSuffix(filepath.Base(inputFile), inputExt)
-			inputDir := filepath.Dir(inputFile)
+		source := probeAudioInfo(job.ctx, inputFile)
+		sampleRate, bitDepth := req.targetFor(source)
+		if sampleRate == 0 && bitDepth == 0 {
+			result.Success = true
+			result.Skipped = true
+			fmt.Printf("[Resample] Skipped (no reduction needed): %s\n", inputFile)
+			job.report(idx, inputFile, audioJobStatusDone, 100)
+			return
+		}
 
-			outputDir := filepath.Join(inputDir, folderLabel)
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				result.Error = fmt.Sprintf("failed to create output directory: %v", err)
-				result.Success = false
-				mu.Lock()
-				results[idx] = result
-				mu.Unlock()
-				return
+		inputExt := strings.ToLower(filepath.Ext(inputFile))
+		baseName := strings.TrimSuffix(filepath.Base(inputFile), inputExt)
+		inputDir := filepath.Dir(inputFile)
+
+		var outputFile string
+		switch outputMode {
+		case resampleOutputInPlace:
+			outputFile = inputFile
+		case resampleOutputMirror:
+			rel, err := filepath.Rel(sourceRoot, inputFile)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				rel = filepath.Base(inputFile)
 			}
+			outputFile = filepath.Join(req.OutputRoot, strings.TrimSuffix(rel, filepath.Ext(rel))+".flac")
+		default:
+			outputFile = filepath.Join(inputDir, folderLabel, baseName+".flac")
+		}
 
-			outputFile := filepath.Join(outputDir, baseName+".flac")
-			result.OutputFile = outputFile
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+			result.Error = fmt.Sprintf("failed to create output directory: %v", err)
+			result.Success = false
+			job.report(idx, inputFile, audioJobStatusFailed, 0)
+			return
+		}
+		result.OutputFile = outputFile
 
-			args := []string{
-				"-i", inputFile,
-				"-y",
-			}
+		encodeFile := outputFile
+		if outputMode == resampleOutputInPlace {
+			encodeFile = filepath.Join(inputDir, baseName+".resampling.flac")
+		}
 
-			if req.BitDepth != "" {
-				switch req.BitDepth {
-				case "16":
-					args = append(args, "-c:a", "flac", "-sample_fmt", "s16")
-				case "24":
-					args = append(args, "-c:a", "flac", "-sample_fmt", "s32", "-bits_per_raw_sample", "24")
-				default:
-					args = append(args, "-c:a", "flac")
-				}
-			} else {
-				args = append(args, "-c:a", "flac")
-			}
+		args := []string{
+			"-i", inputFile,
+			"-y",
+			"-map", "0:a",
+		}
 
-			if req.SampleRate != "" {
-				args = append(args, "-ar", req.SampleRate)
-			}
+		switch bitDepth {
+		case 16:
+			args = append(args, "-c:a", "flac", "-sample_fmt", "s16")
+		case 24:
+			args = append(args, "-c:a", "flac", "-sample_fmt", "s32", "-bits_per_raw_sample", "24")
+		default:
+			args = append(args, "-c:a", "flac")
+		}
+
+		if filter := req.resampleFilter(sampleRate, bitDepth, source); filter != "" {
+			args = append(args, "-af", filter)
+		}
+		if sampleRate > 0 {
+			args = append(args, "-ar", strconv.Itoa(sampleRate))
+		}
 
-			args = append(args, "-map_metadata", "0")
-			args = append(args, outputFile)
+		args = append(args, "-map_metadata", "0")
+		args = append(args, encodeFile)
 
-			fmt.Printf("[Resample] %s -> %s\n", inputFile, outputFile)
+		fmt.Printf("[Resample] %s -> %s\n", inputFile, outputFile)
 
-			cmd := exec.Command(ffmpegPath, args...)
-			setHideWindow(cmd)
-			output, err := cmd.CombinedOutput()
-			if err != nil {
+		duration, _ := GetAudioDuration(inputFile)
+		output, err := runFFmpegWithProgress(job.ctx, ffmpegPath, args, duration, func(percent float64) {
+			job.report(idx, inputFile, audioJobStatusRunning, percent)
+		})
+		if err != nil {
+			if job.statusForError(err) == audioJobStatusCancelled {
+				result.Error = "cancelled"
+			} else {
 				result.Error = fmt.Sprintf("resampling failed: %s - %s", err.Error(), string(output))
+			}
+			os.Remove(encodeFile)
+			result.Success = false
+			job.report(idx, inputFile, job.statusForError(err), 0)
+			return
+		}
+
+		if err := copyFlacMetadataBlocks(inputFile, encodeFile, flac.VorbisComment, flac.Picture, flac.Application); err != nil {
+			if outputMode == resampleOutputInPlace {
+				os.Remove(encodeFile)
+				result.Error = fmt.Sprintf("failed to copy tags, original left unchanged: %v", err)
 				result.Success = false
-				mu.Lock()
-				results[idx] = result
-				mu.Unlock()
+				job.report(idx, inputFile, audioJobStatusFailed, 0)
 				return
 			}
+			fmt.Printf("[Resample] Warning: Failed to copy tags: %v\n", err)
+		}
 
-			result.Success = true
-			fmt.Printf("[Resample] Done: %s\n", outputFile)
-			mu.Lock()
-			results[idx] = result
-			mu.Unlock()
-		}(i, inputFile)
-	}
+		if outputMode == resampleOutputInPlace {
+			backupFile := ""
+			if req.Backup {
+				backupFile = uniqueBackupPath(inputFile)
+				if err := os.Rename(inputFile, backupFile); err != nil {
+					os.Remove(encodeFile)
+					result.Error = fmt.Sprintf("failed to back up original: %v", err)
+					result.Success = false
+					job.report(idx, inputFile, audioJobStatusFailed, 0)
+					return
+				}
+			}
+			if err := os.Rename(encodeFile, inputFile); err != nil {
+				os.Remove(encodeFile)
+				result.Error = fmt.Sprintf("failed to replace original: %v", err)
+				if backupFile != "" {
+					if restoreErr := os.Rename(backupFile, inputFile); restoreErr != nil {
+						result.Error = fmt.Sprintf("%s; original kept at %s", result.Error, backupFile)
+					}
+				}
+				result.Success = false
+				job.report(idx, inputFile, audioJobStatusFailed, 0)
+				return
+			}
+			result.BackupFile = backupFile
+		}
+
+		result.Success = true
+		fmt.Printf("[Resample] Done: %s\n", outputFile)
+		job.report(idx, inputFile, audioJobStatusDone, 100)
+	})
 
-	wg.Wait()
 	return results, nil
 }
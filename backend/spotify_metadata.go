@@ -1389,6 +1389,8 @@ func (c *SpotifyMetadataClient) formatArtistDiscographyData(ctx context.Context,
 		allTracks = append(allTracks, res.tracks...)
 	}
 
+	albumList, allTracks = filterDiscographyByExplicitPreference(albumList, allTracks)
+
 	return &ArtistDiscographyPayload{
 		ArtistInfo: info,
 		AlbumList:  albumList,
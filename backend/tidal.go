@@ -70,8 +70,8 @@ func buildTidalOutputPath(outputDir, filenameFormat string, includeTrackNumber b
 		albumArtistForFile = sanitizeFilename(GetFirstArtist(spotifyAlbumArtist))
 	}
 
-	trackTitleForFile := sanitizeFilename(spotifyTrackName)
-	albumTitleForFile := sanitizeFilename(spotifyAlbumName)
+	trackTitleForFile := sanitizeFilename(ResolveFilenameTitle(spotifyTrackName))
+	albumTitleForFile := sanitizeFilename(ResolveFilenameTitle(spotifyAlbumName))
 
 	filename := buildTidalFilename(trackTitleForFile, artistNameForFile, albumTitleForFile, albumArtistForFile, spotifyReleaseDate, spotifyTrackNumber, spotifyDiscNumber, filenameFormat, includeTrackNumber, position, useAlbumTrackNumber, isrcOverride)
 	outputFilename := filepath.Join(outputDir, filename)
@@ -147,18 +147,13 @@ func finalizeTidalDownload(outputFilename, spotifyTrackName, spotifyArtistName,
 
 	fmt.Println("Adding metadata...")
 
-	coverPath := ""
-	if spotifyCoverURL != "" {
-		coverPath = outputFilename + ".cover.jpg"
-		coverClient := NewCoverClient()
-		if err := coverClient.DownloadCoverToPath(spotifyCoverURL, coverPath, embedMaxQualityCover); err != nil {
-			fmt.Printf("Warning: Failed to download Spotify cover: %v\n", err)
-			coverPath = ""
-		} else {
-			defer os.Remove(coverPath)
-			fmt.Println("Spotify cover downloaded")
-		}
-	}
+	coverPath, cleanupCover := prepareTrackArtwork(outputFilename, spotifyCoverURL, embedMaxQualityCover, CoverLookup{
+		SpotifyURL: spotifyURL,
+		TrackName:  spotifyTrackName,
+		ArtistName: spotifyArtistName,
+		AlbumName:  spotifyAlbumName,
+	})
+	defer cleanupCover()
 
 	trackNumberToEmbed := spotifyTrackNumber
 	if trackNumberToEmbed == 0 {
@@ -184,7 +179,8 @@ func finalizeTidalDownload(outputFilename, spotifyTrackName, spotifyArtistName,
 		Description: "https://github.com/spotbye/SpotiFLAC",
 		ISRC:        isrc,
 		UPC:         upc,
-		Genre:       mbMeta.Genre,
+		Genre:       ResolveGenreFromSources(GenreSourceInput{MusicBrainz: mbMeta.Genre, SpotifyTrackID: spotifyURL, ISRC: isrc, TrackName: spotifyTrackName, ArtistName: spotifyArtistName, AlbumName: spotifyAlbumName}, useSingleGenre, embedGenre),
+		Advisory:    ResolveContentAdvisory(spotifyURL, trackTitle, false),
 	}
 
 	if err := EmbedMetadata(outputFilename, metadata, coverPath); err != nil {
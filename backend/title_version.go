@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"regexp"
+	"strings"
+)
+
+type TitleVersionInfo struct {
+	Title    string   `json:"title"`
+	Versions []string `json:"versions"`
+	Kinds    []string `json:"kinds"`
+}
+
+var (
+	titleTrailingBracketPattern = regexp.MustCompile(`\s*[\(\[]([^\(\)\[\]]+)[\)\]]\s*$`)
+	titleTrailingDashPattern    = regexp.MustCompile(`\s+[-–—]\s+([^-–—]+)$`)
+	titleVersionGenericPattern  = regexp.MustCompile(`(?i)\b(version|edition|bonus track|extended)\b|^(single|explicit|clean|censored|soundtrack)$|^from\s+(["“'‘]|the\s.*\b(motion picture|film|movie|series|soundtrack|musical)\b)`)
+)
+
+var titleVersionKinds = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"Remaster", regexp.MustCompile(`(?i)\bremaster(ed)?\b`)},
+	{"Live", regexp.MustCompile(`(?i)\blive\b`)},
+	{"Deluxe", regexp.MustCompile(`(?i)\b(deluxe|expanded)\b`)},
+	{"Anniversary", regexp.MustCompile(`(?i)\banniversary\b`)},
+	{"Remix", regexp.MustCompile(`(?i)\b(remix|mix|rework)\b`)},
+	{"Edit", regexp.MustCompile(`(?i)\bedit\b`)},
+	{"Acoustic", regexp.MustCompile(`(?i)\b(acoustic|unplugged)\b`)},
+	{"Demo", regexp.MustCompile(`(?i)\bdemo\b`)},
+	{"Instrumental", regexp.MustCompile(`(?i)\binstrumental\b`)},
+	{"Mono", regexp.MustCompile(`(?i)\bmono\b`)},
+	{"Stereo", regexp.MustCompile(`(?i)\bstereo\b`)},
+	{"Re-recorded", regexp.MustCompile(`(?i)\b(re-?recorded|taylor's version)\b`)},
+}
+
+var significantTitleVersionKinds = map[string]struct{}{
+	"Live":         {},
+	"Remix":        {},
+	"Acoustic":     {},
+	"Demo":         {},
+	"Instrumental": {},
+}
+
+func classifyTitleVersionSegment(segment string) ([]string, bool) {
+	segment = strings.TrimSpace(segment)
+	if segment == "" {
+		return nil, false
+	}
+
+	var kinds []string
+	for _, entry := range titleVersionKinds {
+		if entry.pattern.MatchString(segment) {
+			kinds = append(kinds, entry.kind)
+		}
+	}
+
+	if len(kinds) > 0 || titleVersionGenericPattern.MatchString(segment) {
+		return kinds, true
+	}
+	return nil, false
+}
+
+func ParseTitleVersion(title string) TitleVersionInfo {
+	working := strings.TrimSpace(title)
+	info := TitleVersionInfo{Title: working}
+	if working == "" {
+		return info
+	}
+
+	var kept []string
+	seenKinds := make(map[string]struct{})
+	for {
+		match := titleTrailingBracketPattern.FindStringSubmatchIndex(working)
+		if match == nil {
+			match = titleTrailingDashPattern.FindStringSubmatchIndex(working)
+		}
+		if match == nil || match[0] == 0 {
+			break
+		}
+
+		raw := working[match[0]:]
+		segment := working[match[2]:match[3]]
+		working = working[:match[0]]
+
+		kinds, isVersion := classifyTitleVersionSegment(segment)
+		if !isVersion {
+			kept = append([]string{raw}, kept...)
+			continue
+		}
+
+		info.Versions = append([]string{strings.TrimSpace(segment)}, info.Versions...)
+		for _, kind := range kinds {
+			seenKinds[kind] = struct{}{}
+		}
+	}
+
+	if len(info.Versions) == 0 {
+		return info
+	}
+
+	base := strings.TrimSpace(working + strings.Join(kept, ""))
+	if base != "" {
+		info.Title = base
+	}
+
+	for _, entry := range titleVersionKinds {
+		if _, exists := seenKinds[entry.kind]; exists {
+			info.Kinds = append(info.Kinds, entry.kind)
+		}
+	}
+
+	return info
+}
+
+func (info TitleVersionInfo) Version() string {
+	return strings.Join(info.Versions, " / ")
+}
+
+func (info TitleVersionInfo) SignificantKinds() []string {
+	var kinds []string
+	for _, kind := range info.Kinds {
+		if _, ok := significantTitleVersionKinds[kind]; ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func sameTitleVersionKinds(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func ResolveDisplayTitle(title string) string {
+	if !GetCleanTitlesSetting() {
+		return title
+	}
+	return ParseTitleVersion(title).Title
+}
+
+func ResolveFilenameTitle(title string) string {
+	if !GetCleanTitlesSetting() {
+		return title
+	}
+	return significantVersionTitle(title)
+}
+
+func significantVersionTitle(title string) string {
+	info := ParseTitleVersion(title)
+	filenameTitle := info.Title
+	for _, version := range info.Versions {
+		kinds, _ := classifyTitleVersionSegment(version)
+		if len((TitleVersionInfo{Kinds: kinds}).SignificantKinds()) > 0 {
+			filenameTitle += " (" + version + ")"
+		}
+	}
+	return filenameTitle
+}
+
+func resolveTitleVersionMetadata(metadata Metadata) Metadata {
+	titleInfo := ParseTitleVersion(metadata.Title)
+	albumInfo := ParseTitleVersion(metadata.Album)
+
+	if metadata.Version == "" {
+		metadata.Version = titleInfo.Version()
+	}
+	if metadata.Edition == "" {
+		metadata.Edition = albumInfo.Version()
+	}
+	if metadata.VersionType == "" {
+		seen := make(map[string]struct{})
+		var kinds []string
+		for _, kind := range append(titleInfo.Kinds, albumInfo.Kinds...) {
+			if _, exists := seen[kind]; exists {
+				continue
+			}
+			seen[kind] = struct{}{}
+			kinds = append(kinds, kind)
+		}
+		metadata.VersionType = strings.Join(kinds, displayMetadataSeparator(metadata.Separator))
+	}
+
+	if GetCleanTitlesSetting() {
+		metadata.Title = titleInfo.Title
+		metadata.Album = albumInfo.Title
+	}
+
+	return metadata
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTitleVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		title    string
+		versions []string
+		kinds    []string
+	}{
+		{"Song", "Song", nil, nil},
+		{"Song - 2011 Remaster", "Song", []string{"2011 Remaster"}, []string{"Remaster"}},
+		{"Song - Remastered 2009", "Song", []string{"Remastered 2009"}, []string{"Remaster"}},
+		{"Album (Deluxe Edition)", "Album", []string{"Deluxe Edition"}, []string{"Deluxe"}},
+		{"Song - Live", "Song", []string{"Live"}, []string{"Live"}},
+		{"Song (Live at Wembley) - 2011 Remaster", "Song", []string{"Live at Wembley", "2011 Remaster"}, []string{"Remaster", "Live"}},
+		{"Song (Remix)", "Song", []string{"Remix"}, []string{"Remix"}},
+		{"Song - Club Mix", "Song", []string{"Club Mix"}, []string{"Remix"}},
+		{"Song (Mixed)", "Song (Mixed)", nil, nil},
+		{"Song - Radio Edit", "Song", []string{"Radio Edit"}, []string{"Edit"}},
+		{"Song - Single Version", "Song", []string{"Single Version"}, nil},
+		{"Song - Single", "Song", []string{"Single"}, nil},
+		{"Single Ladies (Put a Ring on It)", "Single Ladies (Put a Ring on It)", nil, nil},
+		{"Song (Clean)", "Song", []string{"Clean"}, nil},
+		{"Song (Explicit)", "Song", []string{"Explicit"}, nil},
+		{"Keep It (Clean Up Your Room)", "Keep It (Clean Up Your Room)", nil, nil},
+		{"Let It Go - From \"Frozen\"", "Let It Go", []string{"From \"Frozen\""}, nil},
+		{"Theme - From the Motion Picture Soundtrack", "Theme", []string{"From the Motion Picture Soundtrack"}, nil},
+		{"Postcard (From Paris)", "Postcard (From Paris)", nil, nil},
+		{"Love Story (Taylor's Version)", "Love Story", []string{"Taylor's Version"}, []string{"Re-recorded"}},
+		{"Song (feat. Someone)", "Song (feat. Someone)", nil, nil},
+		{"Song (feat. Someone) - Acoustic", "Song (feat. Someone)", []string{"Acoustic"}, []string{"Acoustic"}},
+		{"Anti-Hero", "Anti-Hero", nil, nil},
+		{"(Live)", "(Live)", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseTitleVersion(tt.input)
+			if got.Title != tt.title {
+				t.Errorf("title = %q, want %q", got.Title, tt.title)
+			}
+			if !reflect.DeepEqual(got.Versions, tt.versions) {
+				t.Errorf("versions = %q, want %q", got.Versions, tt.versions)
+			}
+			if !reflect.DeepEqual(got.Kinds, tt.kinds) {
+				t.Errorf("kinds = %q, want %q", got.Kinds, tt.kinds)
+			}
+		})
+	}
+}
+
+func TestSignificantVersionTitle(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Song", "Song"},
+		{"Song - 2011 Remaster", "Song"},
+		{"Song - Live", "Song (Live)"},
+		{"Song (Remix)", "Song (Remix)"},
+		{"Song (Live at Wembley) - 2011 Remaster", "Song (Live at Wembley)"},
+		{"Song - Acoustic - Radio Edit", "Song (Acoustic)"},
+		{"Album (Deluxe Edition)", "Album"},
+	}
+
+	for _, tt := range tests {
+		if got := significantVersionTitle(tt.input); got != tt.want {
+			t.Errorf("significantVersionTitle(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	seen := map[string]string{}
+	for _, input := range []string{"Song", "Song - Live", "Song (Remix)", "Song - Acoustic"} {
+		got := significantVersionTitle(input)
+		if other, ok := seen[got]; ok {
+			t.Errorf("%q and %q both map to %q", other, input, got)
+		}
+		seen[got] = input
+	}
+}
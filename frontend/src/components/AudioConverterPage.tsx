@@ -1,12 +1,14 @@
-import { useState, useCallback, useEffect } from "react";
+import { useState, useCallback, useEffect, useRef } from "react";
 import { Button } from "@/components/ui/button";
 import { Label } from "@/components/ui/label";
 import { ToggleGroup, ToggleGroupItem, } from "@/components/ui/toggle-group";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue, } from "@/components/ui/select";
 import { Upload, X, CheckCircle2, AlertCircle, Trash2, FileMusic, WandSparkles, } from "lucide-react";
 import { Spinner } from "@/components/ui/spinner";
-import { ConvertAudio, SelectAudioFiles, SelectFolder, ListAudioFilesInDir, } from "../../wailsjs/go/main/App";
+import { CancelAudioJob, ConvertAudio, SelectAudioFiles, SelectFolder, ListAudioFilesInDir, } from "../../wailsjs/go/main/App";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
-import { OnFileDrop, OnFileDropOff } from "../../wailsjs/runtime/runtime";
+import { EventsOff, EventsOn, OnFileDrop, OnFileDropOff } from "../../wailsjs/runtime/runtime";
+import { getSettings, type ConversionFormat } from "@/lib/settings";
 interface AudioFile {
     path: string;
     name: string;
@@ -15,6 +17,7 @@ interface AudioFile {
     status: "pending" | "converting" | "success" | "error";
     error?: string;
     outputPath?: string;
+    progress?: number;
 }
 function formatFileSize(bytes: number): string {
     if (bytes === 0)
@@ -30,6 +33,21 @@ const BITRATE_OPTIONS = [
     { value: "192k", label: "192k" },
     { value: "128k", label: "128k" },
 ];
+type OutputFormat = ConversionFormat;
+const CUSTOM_PRESET = "custom";
+const OUTPUT_FORMAT_OPTIONS: {
+    value: OutputFormat;
+    label: string;
+    lossless: boolean;
+}[] = [
+    { value: "mp3", label: "MP3", lossless: false },
+    { value: "m4a", label: "M4A", lossless: false },
+    { value: "opus", label: "Opus", lossless: false },
+    { value: "ogg", label: "OGG", lossless: false },
+    { value: "flac", label: "FLAC", lossless: true },
+    { value: "wav", label: "WAV", lossless: true },
+    { value: "aiff", label: "AIFF", lossless: true },
+];
 const M4A_CODEC_OPTIONS = [
     { value: "aac", label: "AAC" },
     { value: "alac", label: "ALAC" },
@@ -51,12 +69,12 @@ export function AudioConverterPage() {
         }
         return [];
     });
-    const [outputFormat, setOutputFormat] = useState<"mp3" | "m4a">(() => {
+    const [outputFormat, setOutputFormat] = useState<OutputFormat>(() => {
         try {
             const saved = sessionStorage.getItem(STORAGE_KEY);
             if (saved) {
                 const parsed = JSON.parse(saved);
-                if (parsed.outputFormat === "mp3" || parsed.outputFormat === "m4a") {
+                if (OUTPUT_FORMAT_OPTIONS.some((option) => option.value === parsed.outputFormat)) {
                     return parsed.outputFormat;
                 }
             }
@@ -93,14 +111,50 @@ export function AudioConverterPage() {
         }
         return "aac";
     });
+    const [preset, setPreset] = useState<string>(() => {
+        try {
+            const saved = sessionStorage.getItem(STORAGE_KEY);
+            if (saved) {
+                const parsed = JSON.parse(saved);
+                if (typeof parsed.preset === "string" && parsed.preset) {
+                    return parsed.preset;
+                }
+            }
+        }
+        catch (err) {
+        }
+        return CUSTOM_PRESET;
+    });
+    const conversionPresets = getSettings().conversionPresets;
     const [converting, setConverting] = useState(false);
+    const jobIdRef = useRef("");
+    useEffect(() => {
+        EventsOn("audio-job:progress", (progress: {
+            job_id: string;
+            file: string;
+            percent: number;
+        }) => {
+            if (progress.job_id !== jobIdRef.current)
+                return;
+            setFiles((prev) => prev.map((f) => f.path === progress.file ? { ...f, progress: progress.percent } : f));
+        });
+        return () => {
+            EventsOff("audio-job:progress");
+        };
+    }, []);
+    const handleCancel = async () => {
+        if (jobIdRef.current) {
+            await CancelAudioJob(jobIdRef.current);
+        }
+    };
     const [isDragging, setIsDragging] = useState(false);
     const [isFullscreen, setIsFullscreen] = useState(false);
     const saveState = useCallback((stateToSave: {
         files: AudioFile[];
-        outputFormat: "mp3" | "m4a";
+        outputFormat: OutputFormat;
         bitrate: string;
         m4aCodec: "aac" | "alac";
+        preset: string;
     }) => {
         try {
             sessionStorage.setItem(STORAGE_KEY, JSON.stringify(stateToSave));
@@ -110,13 +164,19 @@ export function AudioConverterPage() {
         }
     }, []);
     useEffect(() => {
-        saveState({ files, outputFormat, bitrate, m4aCodec });
-    }, [files, outputFormat, bitrate, m4aCodec, saveState]);
+        saveState({ files, outputFormat, bitrate, m4aCodec, preset });
+    }, [files, outputFormat, bitrate, m4aCodec, preset, saveState]);
+    useEffect(() => {
+        if (preset !== CUSTOM_PRESET && !conversionPresets.some((p) => p.name === preset)) {
+            setPreset(CUSTOM_PRESET);
+        }
+    }, [preset, conversionPresets]);
     useEffect(() => {
         if (files.length === 0)
             return;
         const allMP3 = files.every((f) => f.format === "mp3");
-        if (allMP3 && outputFormat !== "m4a") {
+        const isLossless = OUTPUT_FORMAT_OPTIONS.some((option) => option.value === outputFormat && option.lossless);
+        if (allMP3 && (outputFormat === "mp3" || isLossless)) {
             setOutputFormat("m4a");
         }
         const hasFlac = files.some((f) => f.format === "flac");
@@ -124,8 +184,10 @@ export function AudioConverterPage() {
             setM4aCodec("aac");
         }
     }, [files, outputFormat, m4aCodec]);
-    const isFormatDisabled = files.length > 0 && files.every((f) => f.format === "mp3");
+    const allMP3Files = files.length > 0 && files.every((f) => f.format === "mp3");
     const hasFlacFiles = files.some((f) => f.format === "flac");
+    const formatOptions = OUTPUT_FORMAT_OPTIONS.filter((option) => !(allMP3Files && (option.value === "mp3" || option.lossless)));
+    const isLosslessOutput = OUTPUT_FORMAT_OPTIONS.some((option) => option.value === outputFormat && option.lossless);
     useEffect(() => {
         const checkFullscreen = () => {
             const isMaximized = window.innerHeight >= window.screen.height * 0.9;
@@ -253,15 +315,19 @@ export function AudioConverterPage() {
             const inputPaths = files.map((f) => f.path);
             setFiles((prev) => prev.map((f) => {
                 if (inputPaths.includes(f.path)) {
-                    return { ...f, status: "converting" as const, error: undefined };
+                    return { ...f, status: "converting" as const, error: undefined, progress: 0 };
                 }
                 return f;
             }));
+            const jobId = `convert-${Date.now()}`;
+            jobIdRef.current = jobId;
             const results = await ConvertAudio({
+                job_id: jobId,
                 input_files: inputPaths,
                 output_format: outputFormat,
                 bitrate: bitrate,
                 codec: outputFormat === "m4a" ? m4aCodec : "",
+                preset: preset === CUSTOM_PRESET ? "" : preset,
             });
             setFiles((prev) => prev.map((f) => {
                 const result = results.find((r) => r.input_file === f.path || r.input_file.toLowerCase() === f.path.toLowerCase());
@@ -372,22 +438,34 @@ export function AudioConverterPage() {
                 <div className="space-y-2 pb-4 border-b shrink-0">
 
                     <div className="flex items-center gap-4">
-                        <div className="flex items-center gap-2">
+                        {conversionPresets.length > 0 && (<div className="flex items-center gap-2">
+                            <Label className="whitespace-nowrap">Preset:</Label>
+                            <Select value={preset} onValueChange={setPreset}>
+                                <SelectTrigger className="w-[180px]">
+                                    <SelectValue placeholder="Select a preset"/>
+                                </SelectTrigger>
+                                <SelectContent>
+                                    <SelectItem value={CUSTOM_PRESET}>Custom</SelectItem>
+                                    {conversionPresets.map((option) => (<SelectItem key={option.name} value={option.name}>
+                                        {option.name}
+                                    </SelectItem>))}
+                                </SelectContent>
+                            </Select>
+                        </div>)}
+
+                        {preset === CUSTOM_PRESET && (<div className="flex items-center gap-2">
                             <Label className="whitespace-nowrap">Format:</Label>
                             <ToggleGroup type="single" variant="outline" value={outputFormat} onValueChange={(value) => {
-                if (value && !isFormatDisabled)
-                    setOutputFormat(value as "mp3" | "m4a");
-            }} disabled={isFormatDisabled}>
-                                {!isFormatDisabled && (<ToggleGroupItem value="mp3" aria-label="MP3">
-                                    MP3
-                                </ToggleGroupItem>)}
-                                <ToggleGroupItem value="m4a" aria-label="M4A" disabled={isFormatDisabled}>
-                                    M4A
-                                </ToggleGroupItem>
+                if (value)
+                    setOutputFormat(value as OutputFormat);
+            }}>
+                                {formatOptions.map((option) => (<ToggleGroupItem key={option.value} value={option.value} aria-label={option.label}>
+                                    {option.label}
+                                </ToggleGroupItem>))}
                             </ToggleGroup>
-                        </div>
+                        </div>)}
 
-                        {outputFormat === "m4a" && hasFlacFiles && (<div className="flex items-center gap-2">
+                        {preset === CUSTOM_PRESET && outputFormat === "m4a" && hasFlacFiles && (<div className="flex items-center gap-2">
                             <Label className="whitespace-nowrap">Codec:</Label>
                             <ToggleGroup type="single" variant="outline" value={m4aCodec} onValueChange={(value) => {
                     if (value)
@@ -399,7 +477,7 @@ export function AudioConverterPage() {
                             </ToggleGroup>
                         </div>)}
 
-                        {!(outputFormat === "m4a" && m4aCodec === "alac") && (<div className="flex items-center gap-2">
+                        {preset === CUSTOM_PRESET && !isLosslessOutput && !(outputFormat === "m4a" && m4aCodec === "alac") && (<div className="flex items-center gap-2">
                             <Label className="whitespace-nowrap">Bitrate:</Label>
                             <ToggleGroup type="single" variant="outline" value={bitrate} onValueChange={(value) => {
                     if (value)
@@ -426,6 +504,9 @@ export function AudioConverterPage() {
                         {getStatusIcon(file.status)}
                         <div className="flex-1 min-w-0">
                             <p className="truncate text-sm font-medium">{file.name}</p>
+                            {file.status === "converting" && file.progress !== undefined && (<p className="text-xs text-muted-foreground">
+                                {Math.round(file.progress)}%
+                            </p>)}
                             {file.error && (<p className="truncate text-xs text-destructive">
                                 {file.error}
                             </p>)}
@@ -443,7 +524,11 @@ export function AudioConverterPage() {
                 </div>
 
 
-                <div className="flex justify-center pt-4 border-t shrink-0">
+                <div className="flex justify-center gap-2 pt-4 border-t shrink-0">
+                    {converting && (<Button onClick={handleCancel} variant="outline" size="lg">
+                        <X className="h-4 w-4"/>
+                        Cancel
+                    </Button>)}
                     <Button onClick={handleConvert} disabled={converting || convertableCount === 0} size="lg">
                         {converting ? (<>
                             <Spinner className="h-4 w-4"/>
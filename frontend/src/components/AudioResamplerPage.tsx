@@ -1,12 +1,14 @@
-import { useState, useCallback, useEffect } from "react";
+import { useState, useCallback, useEffect, useRef } from "react";
 import { Button } from "@/components/ui/button";
 import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue, } from "@/components/ui/select";
 import { ToggleGroup, ToggleGroupItem } from "@/components/ui/toggle-group";
-import { Upload, X, CheckCircle2, AlertCircle, Trash2, FileMusic } from "lucide-react";
+import { Upload, X, CheckCircle2, AlertCircle, Trash2, FileMusic, FolderOpen } from "lucide-react";
 import { Spinner } from "@/components/ui/spinner";
-import { SelectAudioFiles, SelectFolder, ListAudioFilesInDir, ResampleAudio } from "../../wailsjs/go/main/App";
+import { CancelAudioJob, SelectAudioFiles, SelectFolder, ListAudioFilesInDir, ResampleAudio } from "../../wailsjs/go/main/App";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
-import { OnFileDrop, OnFileDropOff } from "../../wailsjs/runtime/runtime";
+import { EventsOff, EventsOn, OnFileDrop, OnFileDropOff } from "../../wailsjs/runtime/runtime";
 import { AudioLinesIcon } from "@/components/ui/audio-lines";
 interface AudioFile {
     path: string;
@@ -16,6 +18,8 @@ interface AudioFile {
     status: "pending" | "resampling" | "success" | "error";
     error?: string;
     outputPath?: string;
+    skipped?: boolean;
+    progress?: number;
     srcSampleRate?: number;
     srcBitDepth?: number;
 }
@@ -46,6 +50,58 @@ const BIT_DEPTH_OPTIONS = [
     { value: "16", label: "16-bit" },
     { value: "24", label: "24-bit" },
 ];
+const RESAMPLER_OPTIONS = [
+    { value: "swr", label: "SWR (default)" },
+    { value: "soxr", label: "SoX (soxr)" },
+];
+const PRECISION_OPTIONS = [
+    { value: "20", label: "20-bit" },
+    { value: "24", label: "24-bit" },
+    { value: "28", label: "28-bit (VHQ)" },
+    { value: "33", label: "33-bit" },
+];
+const DITHER_OPTIONS = [
+    { value: "none", label: "None" },
+    { value: "rectangular", label: "Rectangular" },
+    { value: "triangular", label: "Triangular" },
+    { value: "triangular_hp", label: "Triangular HP" },
+];
+const NOISE_SHAPING_OPTIONS = [
+    { value: "none", label: "None" },
+    { value: "lipshitz", label: "Lipshitz" },
+    { value: "shibata", label: "Shibata" },
+    { value: "low_shibata", label: "Low Shibata" },
+    { value: "high_shibata", label: "High Shibata" },
+    { value: "f_weighted", label: "F-weighted" },
+    { value: "e_weighted", label: "E-weighted" },
+    { value: "modified_e_weighted", label: "Modified E-weighted" },
+    { value: "improved_e_weighted", label: "Improved E-weighted" },
+];
+const OUTPUT_MODE_OPTIONS = [
+    { value: "folder", label: "Subfolder" },
+    { value: "in_place", label: "In place" },
+    { value: "mirror", label: "Mirror tree" },
+];
+interface ResampleOptions {
+    resampler: string;
+    precision: string;
+    dither: string;
+    noiseShaping: string;
+    outputMode: string;
+    outputRoot: string;
+    backup: boolean;
+    skipUpsampling: boolean;
+}
+const DEFAULT_RESAMPLE_OPTIONS: ResampleOptions = {
+    resampler: "swr",
+    precision: "28",
+    dither: "triangular",
+    noiseShaping: "none",
+    outputMode: "folder",
+    outputRoot: "",
+    backup: true,
+    skipUpsampling: true,
+};
 const STORAGE_KEY = "spotiflac_audio_resampler_state";
 export function AudioResamplerPage() {
     const [files, setFiles] = useState<AudioFile[]>(() => {
@@ -89,13 +145,50 @@ export function AudioResamplerPage() {
         }
         return "16";
     });
+    const [options, setOptions] = useState<ResampleOptions>(() => {
+        try {
+            const saved = sessionStorage.getItem(STORAGE_KEY);
+            if (saved) {
+                const parsed = JSON.parse(saved);
+                if (parsed.options)
+                    return { ...DEFAULT_RESAMPLE_OPTIONS, ...parsed.options };
+            }
+        }
+        catch (err) {
+        }
+        return DEFAULT_RESAMPLE_OPTIONS;
+    });
+    const updateOptions = (patch: Partial<ResampleOptions>) => {
+        setOptions((prev) => ({ ...prev, ...patch }));
+    };
     const [resampling, setResampling] = useState(false);
+    const jobIdRef = useRef("");
+    useEffect(() => {
+        EventsOn("audio-job:progress", (progress: {
+            job_id: string;
+            file: string;
+            percent: number;
+        }) => {
+            if (progress.job_id !== jobIdRef.current)
+                return;
+            setFiles((prev) => prev.map((f) => f.path === progress.file ? { ...f, progress: progress.percent } : f));
+        });
+        return () => {
+            EventsOff("audio-job:progress");
+        };
+    }, []);
+    const handleCancel = async () => {
+        if (jobIdRef.current) {
+            await CancelAudioJob(jobIdRef.current);
+        }
+    };
     const [isDragging, setIsDragging] = useState(false);
     const [isFullscreen, setIsFullscreen] = useState(false);
     const saveState = useCallback((stateToSave: {
         files: AudioFile[];
         sampleRate: string;
         bitDepth: string;
+        options: ResampleOptions;
     }) => {
         try {
             sessionStorage.setItem(STORAGE_KEY, JSON.stringify(stateToSave));
@@ -105,8 +198,8 @@ export function AudioResamplerPage() {
         }
     }, []);
     useEffect(() => {
-        saveState({ files, sampleRate, bitDepth });
-    }, [files, sampleRate, bitDepth, saveState]);
+        saveState({ files, sampleRate, bitDepth, options });
+    }, [files, sampleRate, bitDepth, options, saveState]);
     useEffect(() => {
         const checkFullscreen = () => {
             const isMaximized = window.innerHeight >= window.screen.height * 0.9;
@@ -249,6 +342,19 @@ export function AudioResamplerPage() {
             OnFileDropOff();
         };
     }, [handleFileDrop]);
+    const handleSelectOutputRoot = async () => {
+        try {
+            const selectedFolder = await SelectFolder(options.outputRoot);
+            if (selectedFolder) {
+                updateOptions({ outputRoot: selectedFolder });
+            }
+        }
+        catch (err) {
+            toast.error("Folder Selection Failed", {
+                description: err instanceof Error ? err.message : "Failed to select folder",
+            });
+        }
+    };
     const removeFile = (path: string) => {
         setFiles((prev) => prev.filter((f) => f.path !== path));
     };
@@ -262,19 +368,37 @@ export function AudioResamplerPage() {
             });
             return;
         }
+        if (options.outputMode === "mirror" && !options.outputRoot) {
+            toast.error("No output folder", {
+                description: "Please choose an output folder for mirror mode",
+            });
+            return;
+        }
         setResampling(true);
         try {
             const inputPaths = files.map((f) => f.path);
             setFiles((prev) => prev.map((f) => {
                 if (inputPaths.includes(f.path)) {
-                    return { ...f, status: "resampling" as const, error: undefined };
+                    return { ...f, status: "resampling" as const, error: undefined, skipped: undefined, progress: 0 };
                 }
                 return f;
             }));
+            const jobId = `resample-${Date.now()}`;
+            jobIdRef.current = jobId;
             const results = await ResampleAudio({
+                job_id: jobId,
                 input_files: inputPaths,
                 sample_rate: sampleRate,
                 bit_depth: bitDepth,
+                resampler: options.resampler,
+                precision: options.resampler === "soxr" ? parseInt(options.precision, 10) : 0,
+                dither: options.dither === "none" ? "" : options.dither,
+                noise_shaping: options.noiseShaping === "none" ? "" : options.noiseShaping,
+                output_mode: options.outputMode,
+                output_root: options.outputRoot,
+                backup: options.backup,
+                only_downsample: options.skipUpsampling,
+                only_reduce_bit_depth: options.skipUpsampling,
             });
             setFiles((prev) => prev.map((f) => {
                 const result = results.find((r: any) => r.input_file === f.path || r.input_file.toLowerCase() === f.path.toLowerCase());
@@ -283,6 +407,7 @@ export function AudioResamplerPage() {
                         ...f,
                         status: result.success ? "success" : "error",
                         error: result.error,
+                        skipped: result.skipped,
                         outputPath: result.output_file,
                     };
                 }
@@ -406,6 +531,95 @@ export function AudioResamplerPage() {
                             </ToggleGroup>
                         </div>
                     </div>
+
+                    <div className="flex flex-wrap items-center gap-4">
+                        <div className="flex items-center gap-2">
+                            <Label className="whitespace-nowrap">Resampler:</Label>
+                            <Select value={options.resampler} onValueChange={(value) => updateOptions({ resampler: value })}>
+                                <SelectTrigger className="w-[140px]">
+                                    <SelectValue />
+                                </SelectTrigger>
+                                <SelectContent>
+                                    {RESAMPLER_OPTIONS.map((option) => (<SelectItem key={option.value} value={option.value}>
+                                            {option.label}
+                                        </SelectItem>))}
+                                </SelectContent>
+                            </Select>
+                        </div>
+
+                        {options.resampler === "soxr" && (<div className="flex items-center gap-2">
+                                <Label className="whitespace-nowrap">Precision:</Label>
+                                <Select value={options.precision} onValueChange={(value) => updateOptions({ precision: value })}>
+                                    <SelectTrigger className="w-[140px]">
+                                        <SelectValue />
+                                    </SelectTrigger>
+                                    <SelectContent>
+                                        {PRECISION_OPTIONS.map((option) => (<SelectItem key={option.value} value={option.value}>
+                                                {option.label}
+                                            </SelectItem>))}
+                                    </SelectContent>
+                                </Select>
+                            </div>)}
+
+                        {bitDepth === "16" && (<>
+                                <div className="flex items-center gap-2">
+                                    <Label className="whitespace-nowrap">Dither:</Label>
+                                    <Select value={options.dither} onValueChange={(value) => updateOptions({ dither: value })}>
+                                        <SelectTrigger className="w-[140px]">
+                                            <SelectValue />
+                                        </SelectTrigger>
+                                        <SelectContent>
+                                            {DITHER_OPTIONS.map((option) => (<SelectItem key={option.value} value={option.value}>
+                                                    {option.label}
+                                                </SelectItem>))}
+                                        </SelectContent>
+                                    </Select>
+                                </div>
+
+                                <div className="flex items-center gap-2">
+                                    <Label className="whitespace-nowrap">Noise Shaping:</Label>
+                                    <Select value={options.noiseShaping} onValueChange={(value) => updateOptions({ noiseShaping: value })}>
+                                        <SelectTrigger className="w-[180px]">
+                                            <SelectValue />
+                                        </SelectTrigger>
+                                        <SelectContent>
+                                            {NOISE_SHAPING_OPTIONS.map((option) => (<SelectItem key={option.value} value={option.value}>
+                                                    {option.label}
+                                                </SelectItem>))}
+                                        </SelectContent>
+                                    </Select>
+                                </div>
+                            </>)}
+                    </div>
+
+                    <div className="flex flex-wrap items-center gap-4">
+                        <div className="flex items-center gap-2">
+                            <Label className="whitespace-nowrap">Output:</Label>
+                            <ToggleGroup type="single" variant="outline" value={options.outputMode} onValueChange={(value) => {
+                if (value)
+                    updateOptions({ outputMode: value });
+            }}>
+                                {OUTPUT_MODE_OPTIONS.map((option) => (<ToggleGroupItem key={option.value} value={option.value} aria-label={option.label}>
+                                        {option.label}
+                                    </ToggleGroupItem>))}
+                            </ToggleGroup>
+                        </div>
+
+                        {options.outputMode === "in_place" && (<div className="flex items-center gap-2">
+                                <Switch id="resample-backup" checked={options.backup} onCheckedChange={(checked) => updateOptions({ backup: checked })}/>
+                                <Label htmlFor="resample-backup" className="cursor-pointer">Keep .bak backup</Label>
+                            </div>)}
+
+                        {options.outputMode === "mirror" && (<Button variant="outline" size="sm" onClick={handleSelectOutputRoot} className="max-w-[280px]">
+                                <FolderOpen className="h-4 w-4"/>
+                                <span className="truncate">{options.outputRoot || "Choose Output Folder"}</span>
+                            </Button>)}
+
+                        <div className="flex items-center gap-2">
+                            <Switch id="resample-skip-upsampling" checked={options.skipUpsampling} onCheckedChange={(checked) => updateOptions({ skipUpsampling: checked })}/>
+                            <Label htmlFor="resample-skip-upsampling" className="cursor-pointer">Only downsample</Label>
+                        </div>
+                    </div>
                 </div>
 
                 <div className="flex items-center justify-between shrink-0">
@@ -426,6 +640,12 @@ export function AudioResamplerPage() {
                                     {getStatusIcon(file.status)}
                                     <div className="flex-1 min-w-0">
                                         <p className="truncate text-sm font-medium">{file.name}</p>
+                                        {file.status === "resampling" && file.progress !== undefined && (<p className="text-xs text-muted-foreground">
+                                                {Math.round(file.progress)}%
+                                            </p>)}
+                                        {file.skipped && (<p className="text-xs text-muted-foreground">
+                                                Skipped, already at or below target
+                                            </p>)}
                                         {file.error && (<p className="truncate text-xs text-destructive">
                                                 {file.error}
                                             </p>)}
@@ -450,7 +670,11 @@ export function AudioResamplerPage() {
             })}
                 </div>
 
-                <div className="flex justify-center pt-4 border-t shrink-0">
+                <div className="flex justify-center gap-2 pt-4 border-t shrink-0">
+                    {resampling && (<Button onClick={handleCancel} variant="outline" size="lg">
+                            <X className="h-4 w-4"/>
+                            Cancel
+                        </Button>)}
                     <Button onClick={handleResample} disabled={resampling || resampleableCount === 0} size="lg">
                         {resampling ? (<>
                                 <Spinner className="h-4 w-4"/>
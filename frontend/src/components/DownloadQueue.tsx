@@ -261,6 +261,11 @@ export function DownloadQueue({ isOpen, onClose }: DownloadQueueProps) {
                 </div>)}
 
 
+                {item.stages?.some((stage) => stage.status === "failed") && (<div className="mt-1.5 text-xs text-amber-600 dark:text-amber-400">
+                  {item.stages.filter((stage) => stage.status === "failed").map((stage) => `${stage.stage}: ${stage.message}`).join(" · ")}
+                </div>)}
+
+
                 {(item.status === "completed" || item.status === "skipped") && item.file_path && (<div className="mt-1.5 text-xs text-muted-foreground truncate font-mono">
                   {item.file_path}
                 </div>)}
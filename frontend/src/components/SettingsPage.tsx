@@ -9,7 +9,7 @@ import { Tooltip, TooltipContent, TooltipTrigger, } from "@/components/ui/toolti
 import { FolderOpen, Save, RotateCcw, Info, ArrowRight, MonitorCog, FolderCog, Router, FolderLock, Plus, Trash2, ExternalLink, PlugZap, Download, Tags } from "lucide-react";
 import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle, } from "@/components/ui/dialog";
 import { Switch } from "@/components/ui/switch";
-import { getSettings, getSettingsWithDefaults, saveSettings, resetToDefaultSettings, applyThemeMode, applyFont, getFontOptions, parseGoogleFontUrl, loadGoogleFontUrl, loadCustomFonts, saveCustomFonts, FOLDER_PRESETS, FILENAME_PRESETS, TEMPLATE_VARIABLES, hasConfiguredCustomTidalApi, sanitizeAutoOrder, type Settings as SettingsType, type FontFamily, type CustomFontFamily, type FolderPreset, type FilenamePreset, type ExistingFileCheckMode, } from "@/lib/settings";
+import { getSettings, getSettingsWithDefaults, saveSettings, resetToDefaultSettings, applyThemeMode, applyFont, getFontOptions, parseGoogleFontUrl, loadGoogleFontUrl, loadCustomFonts, saveCustomFonts, FOLDER_PRESETS, FILENAME_PRESETS, TEMPLATE_VARIABLES, hasConfiguredCustomTidalApi, sanitizeAutoOrder, type Settings as SettingsType, type FontFamily, type CustomFontFamily, type FolderPreset, type FilenamePreset, type ExistingFileCheckMode, type FeaturedArtistPolicy, type ExplicitPreference, type LyricsFormat, type LyricsMode, type AlbumArtworkSidecar, type CoverSource, } from "@/lib/settings";
 import { themes, applyTheme } from "@/lib/themes";
 import { SelectFolder, OpenConfigFolder, CheckCustomTidalAPI } from "../../wailsjs/go/main/App";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
@@ -836,6 +836,96 @@ export function SettingsPage({ onUnsavedChangesChange, onResetRequest, }: Settin
                 </Label>
               </div>
 
+              <div className="space-y-2">
+                <Label className="text-sm">Lyrics Sources</Label>
+                <Select value={tempSettings.lyricsProviders} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                lyricsProviders: value,
+            }))}>
+                  <SelectTrigger className="h-9 w-fit">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="local-lrclib">Local Folder, LRCLIB</SelectItem>
+                    <SelectItem value="lrclib-local">LRCLIB, Local Folder</SelectItem>
+                    <SelectItem value="lrclib">LRCLIB Only</SelectItem>
+                    <SelectItem value="local">Local Folder Only</SelectItem>
+                  </SelectContent>
+                </Select>
+                {tempSettings.lyricsProviders.includes("local") && (<InputWithContext id="lyrics-local-dir" value={tempSettings.lyricsLocalDir} onChange={(e) => setTempSettings((prev) => ({
+                    ...prev,
+                    lyricsLocalDir: e.target.value,
+                }))} placeholder="Folder containing .lrc files"/>)}
+              </div>
+
+              <div className="space-y-2">
+                <Label className="text-sm">Lyrics Format</Label>
+                <Select value={tempSettings.lyricsFormat} onValueChange={(value: LyricsFormat) => setTempSettings((prev) => ({
+                ...prev,
+                lyricsFormat: value,
+            }))}>
+                  <SelectTrigger className="h-9 w-fit">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="lrc">LRC (Line Synced)</SelectItem>
+                    <SelectItem value="elrc">Enhanced LRC (Word Synced)</SelectItem>
+                    <SelectItem value="ttml">TTML (Word Synced)</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+
+              <div className="space-y-2">
+                <Label className="text-sm">Lyrics Output</Label>
+                <div className="flex flex-wrap items-center gap-2">
+                  <Select value={tempSettings.lyricsMode} onValueChange={(value: LyricsMode) => setTempSettings((prev) => ({
+                ...prev,
+                lyricsMode: value,
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="embed">Embed Only</SelectItem>
+                      <SelectItem value="sidecar">Sidecar File Only</SelectItem>
+                      <SelectItem value="both">Embed And Sidecar</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={tempSettings.lyricsLanguage} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                lyricsLanguage: value,
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="auto">Detect Language</SelectItem>
+                      <SelectItem value="eng">English</SelectItem>
+                      <SelectItem value="spa">Spanish</SelectItem>
+                      <SelectItem value="por">Portuguese</SelectItem>
+                      <SelectItem value="fra">French</SelectItem>
+                      <SelectItem value="deu">German</SelectItem>
+                      <SelectItem value="ita">Italian</SelectItem>
+                      <SelectItem value="jpn">Japanese</SelectItem>
+                      <SelectItem value="kor">Korean</SelectItem>
+                      <SelectItem value="zho">Chinese</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={tempSettings.lyricsInstrumentalMarker || "none"} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                lyricsInstrumentalMarker: value === "none" ? "" : value,
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="[Instrumental]">Mark Instrumentals</SelectItem>
+                      <SelectItem value="none">Skip Instrumentals</SelectItem>
+                    </SelectContent>
+                  </Select>
+                </div>
+              </div>
+
               <div className="flex items-center gap-3">
                 <Switch id="embed-max-quality-cover" checked={tempSettings.embedMaxQualityCover} onCheckedChange={(checked) => setTempSettings((prev) => ({
                 ...prev,
@@ -846,6 +936,114 @@ export function SettingsPage({ onUnsavedChangesChange, onResetRequest, }: Settin
                 </Label>
               </div>
 
+              <div className="space-y-2">
+                <Label className="text-sm">Album Artwork</Label>
+                <div className="flex flex-wrap items-center gap-2">
+                  <Select value={tempSettings.coverSource} onValueChange={(value: CoverSource) => setTempSettings((prev) => ({
+                ...prev,
+                coverSource: value,
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="spotify">Spotify Cover</SelectItem>
+                      <SelectItem value="best">Highest Resolution (Spotify, Qobuz, Tidal)</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={tempSettings.albumArtworkSidecar} onValueChange={(value: AlbumArtworkSidecar) => setTempSettings((prev) => ({
+                ...prev,
+                albumArtworkSidecar: value,
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="none">No Folder Artwork</SelectItem>
+                      <SelectItem value="cover">cover.jpg</SelectItem>
+                      <SelectItem value="folder">folder.jpg</SelectItem>
+                      <SelectItem value="both">cover.jpg And folder.jpg</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={String(tempSettings.embeddedCoverMaxSize)} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                embeddedCoverMaxSize: Number(value),
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="0">Embed Original Size</SelectItem>
+                      <SelectItem value="600">Embed Max 600px</SelectItem>
+                      <SelectItem value="1000">Embed Max 1000px</SelectItem>
+                      <SelectItem value="1400">Embed Max 1400px</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={String(tempSettings.embeddedCoverMaxKB)} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                embeddedCoverMaxKB: Number(value),
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="0">Embed Any File Size</SelectItem>
+                      <SelectItem value="300">Embed Max 300KB</SelectItem>
+                      <SelectItem value="500">Embed Max 500KB</SelectItem>
+                      <SelectItem value="1000">Embed Max 1MB</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={String(tempSettings.sidecarCoverMaxKB)} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                sidecarCoverMaxKB: Number(value),
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="0">Cover File Any Size</SelectItem>
+                      <SelectItem value="500">Cover File Max 500KB</SelectItem>
+                      <SelectItem value="1000">Cover File Max 1MB</SelectItem>
+                      <SelectItem value="2000">Cover File Max 2MB</SelectItem>
+                    </SelectContent>
+                  </Select>
+                  <Select value={String(tempSettings.coverJpegQuality)} onValueChange={(value) => setTempSettings((prev) => ({
+                ...prev,
+                coverJpegQuality: Number(value),
+            }))}>
+                    <SelectTrigger className="h-9 w-fit">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="95">JPEG Quality 95</SelectItem>
+                      <SelectItem value="90">JPEG Quality 90</SelectItem>
+                      <SelectItem value="85">JPEG Quality 85</SelectItem>
+                      <SelectItem value="80">JPEG Quality 80</SelectItem>
+                    </SelectContent>
+                  </Select>
+                </div>
+              </div>
+
+              <div className="flex items-center gap-3">
+                <Switch id="embed-artist-picture" checked={tempSettings.embedArtistPicture} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                embedArtistPicture: checked,
+            }))}/>
+                <Label htmlFor="embed-artist-picture" className="cursor-pointer text-sm font-normal">
+                  Embed Artist Picture
+                </Label>
+              </div>
+
+              <div className="flex items-center gap-3">
+                <Switch id="embed-back-cover" checked={tempSettings.embedBackCover} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                embedBackCover: checked,
+            }))}/>
+                <Label htmlFor="embed-back-cover" className="cursor-pointer text-sm font-normal">
+                  Embed Back Cover
+                </Label>
+              </div>
+
               <div className="flex items-center gap-3">
                 <Switch id="embed-genre" checked={tempSettings.embedGenre} onCheckedChange={(checked) => setTempSettings((prev) => ({
                 ...prev,
@@ -865,6 +1063,76 @@ export function SettingsPage({ onUnsavedChangesChange, onResetRequest, }: Settin
                     Use Single Genre
                   </Label>
                 </div>)}
+
+              {tempSettings.embedGenre && (<div className="flex items-center gap-3">
+                  <Switch id="genre-whitelist" checked={tempSettings.genreWhitelist} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                    ...prev,
+                    genreWhitelist: checked,
+                }))}/>
+                  <Label htmlFor="genre-whitelist" className="text-sm cursor-pointer font-normal">
+                    Only Known Genres
+                  </Label>
+                </div>)}
+
+              {tempSettings.embedGenre && (<div className="flex items-center gap-3">
+                  <Switch id="genre-collapse-to-parent" checked={tempSettings.genreCollapseToParent} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                    ...prev,
+                    genreCollapseToParent: checked,
+                }))}/>
+                  <Label htmlFor="genre-collapse-to-parent" className="text-sm cursor-pointer font-normal">
+                    Collapse To Parent Genre
+                  </Label>
+                </div>)}
+
+              {tempSettings.embedGenre && (<div className="space-y-2">
+                  <Label className="text-sm">Genre Sources</Label>
+                  <div className="flex flex-wrap items-center gap-2">
+                    <Select value={tempSettings.genreSources} onValueChange={(value) => setTempSettings((prev) => ({
+                    ...prev,
+                    genreSources: value,
+                }))}>
+                      <SelectTrigger className="h-9 w-fit">
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        <SelectItem value="musicbrainz-spotify-qobuz">MusicBrainz, Spotify, Qobuz</SelectItem>
+                        <SelectItem value="musicbrainz-qobuz-spotify">MusicBrainz, Qobuz, Spotify</SelectItem>
+                        <SelectItem value="spotify-musicbrainz-qobuz">Spotify, MusicBrainz, Qobuz</SelectItem>
+                        <SelectItem value="qobuz-musicbrainz-spotify">Qobuz, MusicBrainz, Spotify</SelectItem>
+                        <SelectItem value="musicbrainz">MusicBrainz Only</SelectItem>
+                      </SelectContent>
+                    </Select>
+                    <Select value={tempSettings.genreSourceMode} onValueChange={(value: "fallback" | "merge") => setTempSettings((prev) => ({
+                    ...prev,
+                    genreSourceMode: value,
+                }))}>
+                      <SelectTrigger className="h-9 w-fit">
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        <SelectItem value="fallback">First Match</SelectItem>
+                        <SelectItem value="merge">Merge All</SelectItem>
+                      </SelectContent>
+                    </Select>
+                  </div>
+                </div>)}
+
+              <div className="space-y-2">
+                <Label className="text-sm">Explicit Version Preference</Label>
+                <Select value={tempSettings.explicitPreference} onValueChange={(value: ExplicitPreference) => setTempSettings((prev) => ({
+                ...prev,
+                explicitPreference: value,
+            }))}>
+                  <SelectTrigger className="h-9 w-fit">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="explicit">Prefer Explicit</SelectItem>
+                    <SelectItem value="clean">Prefer Clean</SelectItem>
+                    <SelectItem value="either">Either</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
             </div>
 
             <div className="space-y-4">
@@ -877,6 +1145,53 @@ export function SettingsPage({ onUnsavedChangesChange, onResetRequest, }: Settin
                   Use First Artist Only
                 </Label>
               </div>
+
+              <div className="space-y-2">
+                <Label className="text-sm">Featured Artists</Label>
+                <Select value={tempSettings.featuredArtistPolicy} onValueChange={(value: FeaturedArtistPolicy) => setTempSettings((prev) => ({
+                ...prev,
+                featuredArtistPolicy: value,
+            }))}>
+                  <SelectTrigger className="h-9 w-fit">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="keep">Keep As Is</SelectItem>
+                    <SelectItem value="artist">Move To Artist</SelectItem>
+                    <SelectItem value="title">Move To Title</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+
+              <div className="flex items-center gap-3">
+                <Switch id="multi-value-artist-tags" checked={tempSettings.multiValueArtistTags} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                multiValueArtistTags: checked,
+            }))}/>
+                <Label htmlFor="multi-value-artist-tags" className="text-sm cursor-pointer font-normal">
+                  Write ARTISTS / ALBUMARTISTS Tags
+                </Label>
+              </div>
+
+              <div className="flex items-center gap-3">
+                <Switch id="artist-sort-tags" checked={tempSettings.artistSortTags} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                artistSortTags: checked,
+            }))}/>
+                <Label htmlFor="artist-sort-tags" className="text-sm cursor-pointer font-normal">
+                  Write Artist Sort Tags
+                </Label>
+              </div>
+
+              <div className="flex items-center gap-3">
+                <Switch id="clean-titles" checked={tempSettings.cleanTitles} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                cleanTitles: checked,
+            }))}/>
+                <Label htmlFor="clean-titles" className="text-sm cursor-pointer font-normal">
+                  Clean Titles (Move Version To Tags)
+                </Label>
+              </div>
             </div>
           </div>)}
 
@@ -85,7 +85,7 @@ export function useDownload(region: string) {
         setDownloadProgress(safeTotalCount > 0 ? Math.min(100, Math.round((safeCompletedCount / safeTotalCount) * 100)) : 0);
         setDownloadRemainingCount(Math.max(0, safeTotalCount - safeCompletedCount));
     };
-    const downloadWithAutoFallback = async (id: string, settings: any, trackName?: string, artistName?: string, albumName?: string, playlistName?: string, position?: number, spotifyId?: string, durationMs?: number, releaseYear?: string, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string) => {
+    const downloadWithAutoFallback = async (id: string, settings: any, trackName?: string, artistName?: string, albumName?: string, playlistName?: string, position?: number, spotifyId?: string, durationMs?: number, releaseYear?: string, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string, explicit?: boolean) => {
         const allowTidal = hasConfiguredCustomTidalApi(settings.customTidalApi);
         const service = settings.downloader === "tidal" && !allowTidal ? "auto" : settings.downloader;
         const query = trackName && artistName ? `${trackName} ${artistName} ` : undefined;
@@ -245,6 +245,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_first_artist_only: settings.useFirstArtistOnly,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
@@ -293,6 +294,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
                         });
@@ -340,6 +342,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
                         });
@@ -406,6 +409,7 @@ export function useDownload(region: string) {
             isrc: resolvedTemplateISRC || undefined,
             copyright: copyright,
             publisher: publisher,
+            explicit,
             use_first_artist_only: settings.useFirstArtistOnly,
             use_single_genre: settings.useSingleGenre,
             embed_genre: settings.embedGenre,
@@ -416,7 +420,7 @@ export function useDownload(region: string) {
         }
         return singleServiceResponse;
     };
-    const downloadWithItemID = async (settings: any, itemID: string, trackName?: string, artistName?: string, albumName?: string, folderName?: string, position?: number, spotifyId?: string, durationMs?: number, isAlbum?: boolean, releaseYear?: string, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string) => {
+    const downloadWithItemID = async (settings: any, itemID: string, trackName?: string, artistName?: string, albumName?: string, folderName?: string, position?: number, spotifyId?: string, durationMs?: number, isAlbum?: boolean, releaseYear?: string, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string, explicit?: boolean) => {
         const allowTidal = hasConfiguredCustomTidalApi(settings.customTidalApi);
         const service = settings.downloader === "tidal" && !allowTidal ? "auto" : settings.downloader;
         const query = trackName && artistName ? `${trackName} ${artistName}` : undefined;
@@ -529,6 +533,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_first_artist_only: settings.useFirstArtistOnly,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
@@ -577,6 +582,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_first_artist_only: settings.useFirstArtistOnly,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
@@ -626,6 +632,7 @@ export function useDownload(region: string) {
                             isrc: resolvedTemplateISRC || undefined,
                             copyright: copyright,
                             publisher: publisher,
+                            explicit,
                             use_first_artist_only: settings.useFirstArtistOnly,
                             use_single_genre: settings.useSingleGenre,
                             embed_genre: settings.embedGenre,
@@ -688,6 +695,7 @@ export function useDownload(region: string) {
             isrc: resolvedTemplateISRC || undefined,
             copyright: copyright,
             publisher: publisher,
+            explicit,
             use_first_artist_only: settings.useFirstArtistOnly,
             use_single_genre: settings.useSingleGenre,
             embed_genre: settings.embedGenre,
@@ -698,7 +706,7 @@ export function useDownload(region: string) {
         }
         return singleServiceResponse;
     };
-    const handleDownloadTrack = async (id: string, trackName?: string, artistName?: string, albumName?: string, spotifyId?: string, playlistName?: string, durationMs?: number, position?: number, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string) => {
+    const handleDownloadTrack = async (id: string, trackName?: string, artistName?: string, albumName?: string, spotifyId?: string, playlistName?: string, durationMs?: number, position?: number, albumArtist?: string, releaseDate?: string, coverUrl?: string, spotifyTrackNumber?: number, spotifyDiscNumber?: number, spotifyTotalTracks?: number, spotifyTotalDiscs?: number, copyright?: string, publisher?: string, explicit?: boolean) => {
         if (!id) {
             toast.error("No ID found for this track");
             return;
@@ -709,7 +717,7 @@ export function useDownload(region: string) {
         setDownloadingTrack(id);
         try {
             const releaseYear = releaseDate?.substring(0, 4);
-            const response = await downloadWithAutoFallback(id, settings, trackName, artistName, albumName, playlistName, position, spotifyId, durationMs, releaseYear, albumArtist || "", releaseDate, coverUrl, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks, spotifyTotalDiscs, copyright, publisher);
+            const response = await downloadWithAutoFallback(id, settings, trackName, artistName, albumName, playlistName, position, spotifyId, durationMs, releaseYear, albumArtist || "", releaseDate, coverUrl, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks, spotifyTotalDiscs, copyright, publisher, explicit);
             if (response.success) {
                 if (response.already_exists) {
                     toast.info(response.message);
@@ -833,7 +841,7 @@ export function useDownload(region: string) {
             setCurrentDownloadInfo({ name: track.name, artists: displayArtist || "" });
             try {
                 const releaseYear = track.release_date?.substring(0, 4);
-                const response = await downloadWithItemID(settings, itemID, track.name, track.artists, track.album_name, folderName, originalIndex + 1, track.spotify_id, track.duration_ms, isAlbum, releaseYear, track.album_artist || "", track.release_date, track.images, track.track_number, track.disc_number, track.total_tracks, track.total_discs, track.copyright, track.publisher);
+                const response = await downloadWithItemID(settings, itemID, track.name, track.artists, track.album_name, folderName, originalIndex + 1, track.spotify_id, track.duration_ms, isAlbum, releaseYear, track.album_artist || "", track.release_date, track.images, track.track_number, track.disc_number, track.total_tracks, track.total_discs, track.copyright, track.publisher, track.is_explicit);
                 if (response.success) {
                     if (response.already_exists) {
                         skippedCount++;
@@ -1006,7 +1014,7 @@ export function useDownload(region: string) {
             setCurrentDownloadInfo({ name: track.name || "", artists: displayArtist || "" });
             try {
                 const releaseYear = track.release_date?.substring(0, 4);
-                const response = await downloadWithItemID(settings, itemID, track.name, track.artists, track.album_name, folderName, originalIndex + 1, track.spotify_id, track.duration_ms, isAlbum, releaseYear, track.album_artist || "", track.release_date, track.images, track.track_number, track.disc_number, track.total_tracks, track.total_discs, track.copyright, track.publisher);
+                const response = await downloadWithItemID(settings, itemID, track.name, track.artists, track.album_name, folderName, originalIndex + 1, track.spotify_id, track.duration_ms, isAlbum, releaseYear, track.album_artist || "", track.release_date, track.images, track.track_number, track.disc_number, track.total_tracks, track.total_discs, track.copyright, track.publisher, track.is_explicit);
                 if (response.success) {
                     if (response.already_exists) {
                         skippedCount++;
@@ -100,6 +100,15 @@ export function useLyrics() {
                     toast.info("Lyrics file already exists");
                     setSkippedLyrics((prev) => new Set(prev).add(spotifyId));
                 }
+                else if (response.instrumental) {
+                    toast.info("Track is instrumental");
+                    if (response.file) {
+                        setDownloadedLyrics((prev) => new Set(prev).add(spotifyId));
+                    }
+                    else {
+                        setSkippedLyrics((prev) => new Set(prev).add(spotifyId));
+                    }
+                }
                 else {
                     toast.success("Lyrics downloaded successfully");
                     setDownloadedLyrics((prev) => new Set(prev).add(spotifyId));
@@ -137,6 +146,7 @@ export function useLyrics() {
         let success = 0;
         let failed = 0;
         let skipped = 0;
+        let instrumental = 0;
         const total = tracksWithSpotifyId.length;
         for (let i = 0; i < tracksWithSpotifyId.length; i++) {
             const track = tracksWithSpotifyId[i];
@@ -203,6 +213,15 @@ export function useLyrics() {
                         skipped++;
                         setSkippedLyrics((prev) => new Set(prev).add(id));
                     }
+                    else if (response.instrumental) {
+                        instrumental++;
+                        if (response.file) {
+                            setDownloadedLyrics((prev) => new Set(prev).add(id));
+                        }
+                        else {
+                            setSkippedLyrics((prev) => new Set(prev).add(id));
+                        }
+                    }
                     else {
                         success++;
                         setDownloadedLyrics((prev) => new Set(prev).add(id));
@@ -229,7 +248,7 @@ export function useLyrics() {
         setIsBulkDownloadingLyrics(false);
         setLyricsDownloadProgress(0);
         if (!stopBulkDownloadRef.current) {
-            toast.success(`Lyrics: ${success} downloaded, ${skipped} skipped, ${failed} failed`);
+            toast.success(`Lyrics: ${success} downloaded, ${instrumental} instrumental, ${skipped} skipped, ${failed} failed`);
         }
     };
     const handleStopLyricsDownload = () => {
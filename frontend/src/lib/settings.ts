@@ -17,6 +17,41 @@ export type FontOption = {
 export type FolderPreset = "none" | "artist" | "album" | "year-album" | "year-artist-album" | "artist-album" | "artist-year-album" | "artist-year-nested-album" | "album-artist" | "album-artist-album" | "album-artist-year-album" | "album-artist-year-nested-album" | "year" | "year-artist" | "custom";
 export type FilenamePreset = "title" | "title-artist" | "artist-title" | "track-title" | "track-title-artist" | "track-artist-title" | "title-album-artist" | "track-title-album-artist" | "artist-album-title" | "track-dash-title" | "disc-track-title" | "disc-track-title-artist" | "custom";
 export type ExistingFileCheckMode = "filename" | "isrc";
+export type FeaturedArtistPolicy = "keep" | "artist" | "title";
+export type ExplicitPreference = "explicit" | "clean" | "either";
+export type LyricsFormat = "lrc" | "elrc" | "ttml";
+export type LyricsMode = "embed" | "sidecar" | "both";
+export type AlbumArtworkSidecar = "none" | "cover" | "folder" | "both";
+export type CoverSource = "spotify" | "best";
+export type ConversionFormat = "mp3" | "m4a" | "opus" | "ogg" | "flac" | "wav" | "aiff";
+export type ConversionMode = "cbr" | "vbr" | "cvbr";
+export interface ConversionPreset {
+    name: string;
+    format: ConversionFormat;
+    codec?: "aac" | "alac";
+    mode?: ConversionMode;
+    bitrate?: string;
+    quality?: number;
+    maxSampleRate?: number;
+    maxBitDepth?: number;
+    channels?: number;
+}
+export type PipelineStageType = "validate" | "verify" | "tag" | "lyrics" | "cover" | "loudness" | "convert" | "move" | "playlist" | "hook";
+export interface PipelineStageConfig {
+    type: PipelineStageType | string;
+    disabled?: boolean;
+    required?: boolean;
+    options?: Record<string, unknown>;
+}
+export interface PipelineProfile {
+    name: string;
+    stages: PipelineStageConfig[];
+}
+export const DEFAULT_CONVERSION_PRESETS: ConversionPreset[] = [
+    { name: "Phone Opus 128", format: "opus", mode: "vbr", bitrate: "128k", channels: 2 },
+    { name: "MP3 V0", format: "mp3", mode: "vbr", quality: 0 },
+    { name: "ALAC archive", format: "m4a", codec: "alac" },
+];
 export interface Settings {
     downloadPath: string;
     downloader: "auto" | "tidal" | "qobuz" | "amazon";
@@ -55,6 +90,34 @@ export interface Settings {
     embedGenre: boolean;
     redownloadWithSuffix: boolean;
     separator: "comma" | "semicolon";
+    featuredArtistPolicy: FeaturedArtistPolicy;
+    multiValueArtistTags: boolean;
+    artistSortTags: boolean;
+    cleanTitles: boolean;
+    genreWhitelist: boolean;
+    genreCollapseToParent: boolean;
+    genreSources: string;
+    genreSourceMode: "fallback" | "merge";
+    explicitPreference: ExplicitPreference;
+    lyricsProviders: string;
+    lyricsLocalDir: string;
+    lyricsProviderTimeouts: Record<string, number>;
+    lyricsFormat: LyricsFormat;
+    lyricsMode: LyricsMode;
+    lyricsLanguage: string;
+    lyricsInstrumentalMarker: string;
+    albumArtworkSidecar: AlbumArtworkSidecar;
+    embeddedCoverMaxSize: number;
+    embedArtistPicture: boolean;
+    embedBackCover: boolean;
+    coverJpegQuality: number;
+    embeddedCoverMaxKB: number;
+    sidecarCoverMaxKB: number;
+    coverSource: CoverSource;
+    conversionPresets: ConversionPreset[];
+    ffmpegVersion: string;
+    pipelineProfile: string;
+    pipelineProfiles: PipelineProfile[];
 }
 export const FOLDER_PRESETS: Record<FolderPreset, {
     label: string;
@@ -198,6 +261,34 @@ export const DEFAULT_SETTINGS: Settings = {
     embedGenre: false,
     redownloadWithSuffix: false,
     separator: "semicolon",
+    featuredArtistPolicy: "keep",
+    multiValueArtistTags: true,
+    artistSortTags: true,
+    cleanTitles: false,
+    genreWhitelist: true,
+    genreCollapseToParent: false,
+    genreSources: "musicbrainz-spotify-qobuz",
+    genreSourceMode: "fallback",
+    explicitPreference: "either",
+    lyricsProviders: "local-lrclib",
+    lyricsLocalDir: "",
+    lyricsProviderTimeouts: {},
+    lyricsFormat: "lrc",
+    lyricsMode: "embed",
+    lyricsLanguage: "auto",
+    lyricsInstrumentalMarker: "[Instrumental]",
+    albumArtworkSidecar: "none",
+    embeddedCoverMaxSize: 0,
+    embedArtistPicture: false,
+    embedBackCover: false,
+    coverJpegQuality: 90,
+    embeddedCoverMaxKB: 0,
+    sidecarCoverMaxKB: 0,
+    coverSource: "spotify",
+    conversionPresets: DEFAULT_CONVERSION_PRESETS,
+    ffmpegVersion: "",
+    pipelineProfile: "default",
+    pipelineProfiles: [],
 };
 export const FONT_OPTIONS: FontOption[] = [
     {
@@ -654,6 +745,103 @@ function normalizeSettingsPayload(settings: SettingsPayload): SettingsPayload {
     if (!("redownloadWithSuffix" in normalized)) {
         normalized.redownloadWithSuffix = false;
     }
+    if (normalized.featuredArtistPolicy !== "artist" && normalized.featuredArtistPolicy !== "title") {
+        normalized.featuredArtistPolicy = "keep";
+    }
+    if (!("multiValueArtistTags" in normalized)) {
+        normalized.multiValueArtistTags = true;
+    }
+    if (!("artistSortTags" in normalized)) {
+        normalized.artistSortTags = true;
+    }
+    if (!("cleanTitles" in normalized)) {
+        normalized.cleanTitles = false;
+    }
+    if (!("genreWhitelist" in normalized)) {
+        normalized.genreWhitelist = true;
+    }
+    if (!("genreCollapseToParent" in normalized)) {
+        normalized.genreCollapseToParent = false;
+    }
+    if (typeof normalized.genreSources !== "string" || normalized.genreSources.trim() === "") {
+        normalized.genreSources = DEFAULT_SETTINGS.genreSources;
+    }
+    if (normalized.genreSourceMode !== "merge") {
+        normalized.genreSourceMode = "fallback";
+    }
+    if (normalized.explicitPreference !== "explicit" && normalized.explicitPreference !== "clean") {
+        normalized.explicitPreference = "either";
+    }
+    if (typeof normalized.lyricsProviders !== "string" || normalized.lyricsProviders.trim() === "") {
+        normalized.lyricsProviders = DEFAULT_SETTINGS.lyricsProviders;
+    }
+    if (typeof normalized.lyricsLocalDir !== "string") {
+        normalized.lyricsLocalDir = "";
+    }
+    if (!normalized.lyricsProviderTimeouts || typeof normalized.lyricsProviderTimeouts !== "object") {
+        normalized.lyricsProviderTimeouts = {};
+    }
+    if (normalized.lyricsFormat !== "elrc" && normalized.lyricsFormat !== "ttml") {
+        normalized.lyricsFormat = "lrc";
+    }
+    if (normalized.lyricsMode !== "sidecar" && normalized.lyricsMode !== "both") {
+        normalized.lyricsMode = "embed";
+    }
+    if (typeof normalized.lyricsLanguage !== "string" || normalized.lyricsLanguage.trim() === "") {
+        normalized.lyricsLanguage = "auto";
+    }
+    if (typeof normalized.lyricsInstrumentalMarker !== "string") {
+        normalized.lyricsInstrumentalMarker = "[Instrumental]";
+    }
+    if (normalized.albumArtworkSidecar !== "cover" && normalized.albumArtworkSidecar !== "folder" && normalized.albumArtworkSidecar !== "both") {
+        normalized.albumArtworkSidecar = "none";
+    }
+    if (typeof normalized.embeddedCoverMaxSize !== "number" || !Number.isFinite(normalized.embeddedCoverMaxSize) || normalized.embeddedCoverMaxSize < 0) {
+        normalized.embeddedCoverMaxSize = 0;
+    }
+    normalized.embedArtistPicture = normalized.embedArtistPicture === true;
+    normalized.embedBackCover = normalized.embedBackCover === true;
+    if (typeof normalized.coverJpegQuality !== "number" || normalized.coverJpegQuality < 50 || normalized.coverJpegQuality > 100) {
+        normalized.coverJpegQuality = 90;
+    }
+    if (typeof normalized.embeddedCoverMaxKB !== "number" || normalized.embeddedCoverMaxKB < 0) {
+        normalized.embeddedCoverMaxKB = 0;
+    }
+    if (typeof normalized.sidecarCoverMaxKB !== "number" || normalized.sidecarCoverMaxKB < 0) {
+        normalized.sidecarCoverMaxKB = 0;
+    }
+    if (normalized.coverSource !== "best") {
+        normalized.coverSource = "spotify";
+    }
+    if (!Array.isArray(normalized.conversionPresets)) {
+        normalized.conversionPresets = DEFAULT_CONVERSION_PRESETS;
+    }
+    else {
+        const customPresets = normalized.conversionPresets.filter((preset: ConversionPreset) => preset &&
+            typeof preset.name === "string" &&
+            preset.name.trim() !== "" &&
+            typeof preset.format === "string");
+        const customNames = new Set(customPresets.map((preset: ConversionPreset) => preset.name.trim().toLowerCase()));
+        normalized.conversionPresets = [
+            ...customPresets,
+            ...DEFAULT_CONVERSION_PRESETS.filter((preset) => !customNames.has(preset.name.toLowerCase())),
+        ];
+    }
+    if (typeof normalized.ffmpegVersion !== "string") {
+        normalized.ffmpegVersion = "";
+    }
+    if (typeof normalized.pipelineProfile !== "string" || normalized.pipelineProfile.trim() === "") {
+        normalized.pipelineProfile = "default";
+    }
+    if (!Array.isArray(normalized.pipelineProfiles)) {
+        normalized.pipelineProfiles = [];
+    }
+    else {
+        normalized.pipelineProfiles = normalized.pipelineProfiles.filter((profile: PipelineProfile) => profile &&
+            typeof profile.name === "string" &&
+            profile.name.trim() !== "" &&
+            Array.isArray(profile.stages));
+    }
     normalized.operatingSystem = detectOS();
     const normalizedCustomFonts = normalizeCustomFonts(normalized.customFonts);
     normalized.customFonts = normalizedCustomFonts;
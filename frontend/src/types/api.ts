@@ -144,6 +144,7 @@ export interface DownloadRequest {
     use_first_artist_only?: boolean;
     use_single_genre?: boolean;
     embed_genre?: boolean;
+    explicit?: boolean;
 }
 export interface DownloadResponse {
     success: boolean;
@@ -214,6 +215,7 @@ export interface LyricsDownloadResponse {
     file?: string;
     error?: string;
     already_exists?: boolean;
+    instrumental?: boolean;
 }
 export interface TrackAvailability {
     spotify_id: string;